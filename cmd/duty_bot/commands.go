@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/app/dutybot"
+	"github.com/gibsn/duty_bot/internal/app/dutybot/cfg"
+	"github.com/gibsn/duty_bot/internal/dutyscheduler"
+)
+
+const (
+	scheduleCommand     = "schedule"
+	migrateStateCommand = "migrate-state"
+	historyCommand      = "history"
+	reportCommand       = "report"
+
+	defaultScheduleLen = 7
+)
+
+// runCommand runs the given subcommand instead of launching the bot.
+func runCommand(config cfg.Config, args []string) error {
+	switch args[0] {
+	case scheduleCommand:
+		return runScheduleCommand(config, args[1:])
+	case migrateStateCommand:
+		return dutybot.MigrateStates(config, os.Stdout)
+	case historyCommand:
+		return runHistoryCommand(config, args[1:])
+	case reportCommand:
+		return runReportCommand(config, args[1:])
+	}
+
+	return fmt.Errorf("unknown command '%s'", args[0])
+}
+
+func runScheduleCommand(config cfg.Config, args []string) error {
+	flags := flag.NewFlagSet(scheduleCommand, flag.ExitOnError)
+
+	project := flags.String("project", "", "project to print schedule for, all projects by default")
+	n := flags.Int("n", defaultScheduleLen, "number of changes to print")
+
+	// errors are handled by flag.ExitOnError
+	_ = flags.Parse(args)
+
+	if *n <= 0 {
+		return fmt.Errorf("number of changes must be positive")
+	}
+
+	return dutybot.PrintSchedule(config, *project, *n, os.Stdout)
+}
+
+func runHistoryCommand(config cfg.Config, args []string) error {
+	flags := flag.NewFlagSet(historyCommand, flag.ExitOnError)
+
+	project := flags.String("project", "", "project to print history for, all projects by default")
+	from := flags.String("from", "", "first day of the history, YYYY-MM-DD")
+	till := flags.String("till", "", "last day of the history, YYYY-MM-DD")
+
+	// errors are handled by flag.ExitOnError
+	_ = flags.Parse(args)
+
+	fromTime, tillTime, err := dutyscheduler.ParseDateRange(*from, *till)
+	if err != nil {
+		return err
+	}
+
+	return dutybot.PrintHistory(config, *project, fromTime, tillTime, os.Stdout)
+}
+
+func runReportCommand(config cfg.Config, args []string) error {
+	flags := flag.NewFlagSet(reportCommand, flag.ExitOnError)
+
+	// the previous month by default
+	year, month, _ := time.Now().Date()
+	first := time.Date(year, month-1, 1, 0, 0, 0, 0, time.Local)
+
+	project := flags.String("project", "", "project to make report for, all projects by default")
+	from := flags.String(
+		"from", first.Format(dutyscheduler.DateLayout), "first day of the report, YYYY-MM-DD",
+	)
+	till := flags.String(
+		"till", first.AddDate(0, 1, -1).Format(dutyscheduler.DateLayout),
+		"last day of the report, YYYY-MM-DD",
+	)
+	format := flags.String("format", string(dutybot.CSVReportFormat), "output format, csv or json")
+
+	// errors are handled by flag.ExitOnError
+	_ = flags.Parse(args)
+
+	if len(*from) == 0 || len(*till) == 0 {
+		return fmt.Errorf("both days of the report must be given")
+	}
+
+	fromTime, tillTime, err := dutyscheduler.ParseDateRange(*from, *till)
+	if err != nil {
+		return err
+	}
+
+	return dutybot.WriteReport(
+		config, *project, fromTime, tillTime, dutybot.ReportFormat(*format), os.Stdout,
+	)
+}
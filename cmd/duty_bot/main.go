@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 
 	"github.com/gibsn/duty_bot/internal/app/dutybot"
@@ -25,6 +26,14 @@ func main() {
 		log.Fatalf("error: config is invalid: %v", err)
 	}
 
+	if flag.NArg() > 0 {
+		if err = runCommand(config, flag.Args()); err != nil {
+			log.Fatalf("fatal: %v", err)
+		}
+
+		return
+	}
+
 	config.Print()
 
 	bot, err := dutybot.NewDutyBot(config)
@@ -1,20 +1,46 @@
 module github.com/gibsn/duty_bot
 
-go 1.14
+go 1.17
 
 require (
 	github.com/anatoliyfedorenko/isdayoff v1.0.2
 	github.com/emersion/go-ical v0.0.0-20200224201310-cd514449c39e
 	github.com/emersion/go-webdav v0.3.1
-	github.com/kr/pretty v0.2.0 // indirect
 	github.com/mail-ru-im/bot-golang v0.0.0-20200509193603-2c56a20fca87
 	github.com/mitchellh/mapstructure v1.4.3
 	github.com/sirupsen/logrus v1.4.2
 	github.com/stretchr/testify v1.7.1
-	golang.org/x/sys v0.0.0-20211210111614-af8b64212486 // indirect
-	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 	gopkg.in/yaml.v2 v2.4.0
+	modernc.org/sqlite v1.20.3
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dustin/go-humanize v1.0.0 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/hako/durafmt v0.0.0-20190612201238-650ed9f29a84 // indirect
+	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/kr/pretty v0.2.0 // indirect
+	github.com/mailru/easyjson v0.0.0-20190626092158-b2ccc519800e // indirect
+	github.com/mattn/go-isatty v0.0.16 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20200410134404-eec4a21b6bb0 // indirect
+	golang.org/x/mod v0.3.0 // indirect
+	golang.org/x/sys v0.0.0-20220811171246-fbc7d0a398ab // indirect
+	golang.org/x/tools v0.0.0-20201124115921-2c860bdd6e78 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
+	lukechampine.com/uint128 v1.2.0 // indirect
+	modernc.org/cc/v3 v3.40.0 // indirect
+	modernc.org/ccgo/v3 v3.16.13 // indirect
+	modernc.org/libc v1.22.2 // indirect
+	modernc.org/mathutil v1.5.0 // indirect
+	modernc.org/memory v1.4.0 // indirect
+	modernc.org/opt v0.1.3 // indirect
+	modernc.org/strutil v1.1.3 // indirect
+	modernc.org/token v1.0.1 // indirect
 )
 
 replace github.com/emersion/go-webdav => github.com/gibsn/go-webdav v0.3.2-0.20220511212135-85f98a968374
@@ -0,0 +1,288 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/dutyscheduler"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+const (
+	projectsPath = "projects"
+	statsPath    = "stats"
+	historyPath  = "history"
+
+	rotateAction = "rotate"
+	skipAction   = "skip"
+	pauseAction  = "pause"
+	resumeAction = "resume"
+
+	personParam = "person"
+	fromParam   = "from"
+	tillParam   = "till"
+)
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrUnknownAction   = errors.New("unknown action")
+)
+
+type scheduler interface {
+	ProjectName() string
+	Status() dutyscheduler.Status
+	Rotate() string
+	Skip(string) error
+	SetPaused(bool)
+	Stats() []dutyscheduler.ApplicantStats
+	History(from, till time.Time) ([]statedumper.HistoryRecord, error)
+}
+
+// Server is an HTTP server that allows to inspect and control schedulers
+// at runtime. All the responses are JSON.
+//
+//	GET  /projects                      — statuses of all projects
+//	GET  /projects/{name}               — status of the given project
+//	GET  /projects/{name}/stats         — amount of duty every applicant has done
+//	GET  /projects/{name}/history       — duty history, ?from=&till= dates are YYYY-MM-DD
+//	POST /projects/{name}/rotate        — change the person of duty immediately
+//	POST /projects/{name}/skip?person=  — make the person skip their next turn
+//	POST /projects/{name}/pause         — stop scheduled changes
+//	POST /projects/{name}/resume        — resume scheduled changes
+type Server struct {
+	schedulers []scheduler
+
+	srv *http.Server
+}
+
+// NewServer creates a new Server for the given schedulers.
+func NewServer(config Config, schedulers []*dutyscheduler.DutyScheduler) *Server {
+	s := &Server{
+		schedulers: make([]scheduler, 0, len(schedulers)),
+	}
+
+	for _, sch := range schedulers {
+		s.schedulers = append(s.schedulers, sch)
+	}
+
+	s.srv = &http.Server{
+		Addr:         config.Addr,
+		Handler:      s,
+		ReadTimeout:  config.Timeout,
+		WriteTimeout: config.Timeout,
+	}
+
+	return s
+}
+
+// Start starts listening on the configured address and serves requests
+// in background.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("could not listen on '%s': %w", s.srv.Addr, err)
+	}
+
+	go func() {
+		if err := s.srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("error: adminapi: server failed: %v", err)
+		}
+	}()
+
+	log.Printf("info: adminapi: listening on '%s'", listener.Addr())
+
+	return nil
+}
+
+// Shutdown stops accepting new requests and waits for the current ones to finish.
+func (s *Server) Shutdown() error {
+	return s.srv.Shutdown(context.Background())
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// [projects, name, action]
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != projectsPath {
+		writeError(w, http.StatusNotFound, fmt.Errorf("'%s': %w", r.URL.Path, cfg.ErrNotSupported))
+		return
+	}
+
+	switch len(parts) {
+	case 1: // nolint: gomnd
+		s.handleList(w, r)
+	case 2: // nolint: gomnd
+		s.handleStatus(w, r, parts[1])
+	case 3: // nolint: gomnd
+		switch parts[2] {
+		case statsPath:
+			s.handleStats(w, r, parts[1])
+			return
+		case historyPath:
+			s.handleHistory(w, r, parts[1])
+			return
+		}
+
+		s.handleAction(w, r, parts[1], parts[2])
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("'%s': %w", r.URL.Path, cfg.ErrNotSupported))
+	}
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	statuses := make([]dutyscheduler.Status, 0, len(s.schedulers))
+
+	for _, sch := range s.schedulers {
+		statuses = append(statuses, sch.Status())
+	}
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request, name string) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	sch, err := s.findScheduler(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sch.Status())
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request, name string) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	sch, err := s.findScheduler(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sch.Stats())
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request, name string) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	sch, err := s.findScheduler(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	from, till, err := dutyscheduler.ParseDateRange(query.Get(fromParam), query.Get(tillParam))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	records, err := sch.History(from, till)
+	if errors.Is(err, dutyscheduler.ErrHistoryNotKept) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, records)
+}
+
+func (s *Server) handleAction(w http.ResponseWriter, r *http.Request, name, action string) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	sch, err := s.findScheduler(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	switch action {
+	case rotateAction:
+		sch.Rotate()
+	case skipAction:
+		person := r.URL.Query().Get(personParam)
+		if len(person) == 0 {
+			writeError(
+				w, http.StatusBadRequest, fmt.Errorf("%s: %w", personParam, cfg.ErrMustNotBeEmpty),
+			)
+			return
+		}
+
+		if err := sch.Skip(person); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	case pauseAction:
+		sch.SetPaused(true)
+	case resumeAction:
+		sch.SetPaused(false)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("'%s': %w", action, ErrUnknownAction))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sch.Status())
+}
+
+func (s *Server) findScheduler(name string) (scheduler, error) {
+	for _, sch := range s.schedulers {
+		if sch.ProjectName() == name {
+			return sch, nil
+		}
+	}
+
+	return nil, fmt.Errorf("'%s': %w", name, ErrProjectNotFound)
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s: %w", r.Method, cfg.ErrNotSupported))
+
+	return false
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("error: adminapi: could not write response: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package adminapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/dutyscheduler"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+type fakeScheduler struct {
+	status  dutyscheduler.Status
+	stats   []dutyscheduler.ApplicantStats
+	history []statedumper.HistoryRecord
+}
+
+func (sch *fakeScheduler) ProjectName() string {
+	return sch.status.Name
+}
+
+func (sch *fakeScheduler) Status() dutyscheduler.Status {
+	return sch.status
+}
+
+func (sch *fakeScheduler) Rotate() string {
+	sch.status.CurrentPerson = "test2"
+	return sch.status.CurrentPerson
+}
+
+func (sch *fakeScheduler) Skip(person string) error {
+	if person != "test1" && person != "test2" {
+		return dutyscheduler.ErrUnknownApplicant
+	}
+
+	return nil
+}
+
+func (sch *fakeScheduler) SetPaused(paused bool) {
+	sch.status.Paused = paused
+}
+
+func (sch *fakeScheduler) Stats() []dutyscheduler.ApplicantStats {
+	return sch.stats
+}
+
+func (sch *fakeScheduler) History(from, till time.Time) ([]statedumper.HistoryRecord, error) {
+	records := []statedumper.HistoryRecord{}
+
+	for _, r := range sch.history {
+		if !r.Time.Before(from) && (till.IsZero() || r.Time.Before(till)) {
+			records = append(records, r)
+		}
+	}
+
+	return records, nil
+}
+
+type serverTestcase struct {
+	method, path string
+	code         int
+	output       interface{}
+}
+
+func TestServer(t *testing.T) {
+	sch := &fakeScheduler{
+		status: dutyscheduler.Status{Name: "test_project", CurrentPerson: "test1"},
+		stats:  []dutyscheduler.ApplicantStats{{Name: "test1", Days: 1.5, DayOffs: 0.5}},
+		history: []statedumper.HistoryRecord{
+			{
+				Time:   time.Date(2024, time.January, 8, 10, 0, 0, 0, time.Local),
+				Person: "test1",
+				Reason: statedumper.ReasonScheduled,
+			},
+			{
+				Time:   time.Date(2024, time.January, 9, 10, 0, 0, 0, time.Local),
+				Person: "test2",
+				Reason: statedumper.ReasonForced,
+			},
+		},
+	}
+
+	srv := &Server{schedulers: []scheduler{sch}}
+
+	paused := sch.status
+	paused.Paused = true
+
+	rotated := paused
+	rotated.CurrentPerson = "test2"
+
+	resumed := rotated
+	resumed.Paused = false
+
+	for _, testcase := range []serverTestcase{
+		{http.MethodGet, "/projects", http.StatusOK, []dutyscheduler.Status{sch.status}},
+		{http.MethodGet, "/projects/test_project", http.StatusOK, sch.status},
+		{http.MethodGet, "/projects/unknown", http.StatusNotFound, nil},
+		{http.MethodGet, "/unknown", http.StatusNotFound, nil},
+		{http.MethodPost, "/projects", http.StatusMethodNotAllowed, nil},
+		{http.MethodGet, "/projects/test_project/rotate", http.StatusMethodNotAllowed, nil},
+		{http.MethodGet, "/projects/test_project/stats", http.StatusOK, sch.stats},
+		{http.MethodPost, "/projects/test_project/stats", http.StatusMethodNotAllowed, nil},
+		{http.MethodGet, "/projects/unknown/stats", http.StatusNotFound, nil},
+		{http.MethodGet, "/projects/test_project/history", http.StatusOK, sch.history},
+		{
+			http.MethodGet, "/projects/test_project/history?from=2024-01-09&till=2024-01-09",
+			http.StatusOK, sch.history[1:],
+		},
+		{
+			http.MethodGet, "/projects/test_project/history?till=2024-01-07",
+			http.StatusOK, []statedumper.HistoryRecord{},
+		},
+		{http.MethodGet, "/projects/test_project/history?from=2024-13-01", http.StatusBadRequest, nil},
+		{http.MethodPost, "/projects/test_project/history", http.StatusMethodNotAllowed, nil},
+		{http.MethodPost, "/projects/test_project/unknown", http.StatusNotFound, nil},
+		{http.MethodPost, "/projects/test_project/pause", http.StatusOK, paused},
+		{http.MethodPost, "/projects/test_project/rotate", http.StatusOK, rotated},
+		{http.MethodPost, "/projects/test_project/skip", http.StatusBadRequest, nil},
+		{http.MethodPost, "/projects/test_project/skip?person=test3", http.StatusBadRequest, nil},
+		{http.MethodPost, "/projects/test_project/skip?person=test1", http.StatusOK, rotated},
+		{http.MethodPost, "/projects/test_project/resume", http.StatusOK, resumed},
+	} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(testcase.method, testcase.path, nil))
+
+		if !assert.Equal(t, testcase.code, w.Code, "%s %s", testcase.method, testcase.path) {
+			continue
+		}
+
+		if testcase.output == nil {
+			var resp errorResponse
+
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.NotEmpty(t, resp.Error, "%s %s", testcase.method, testcase.path)
+
+			continue
+		}
+
+		expected, _ := json.Marshal(testcase.output)
+		assert.JSONEq(t, string(expected), w.Body.String(), "%s %s", testcase.method, testcase.path)
+	}
+}
@@ -0,0 +1,54 @@
+package adminapi
+
+import (
+	"log"
+	"time"
+)
+
+type Config struct {
+	Enabled bool
+
+	Addr    string
+	Timeout time.Duration
+}
+
+const (
+	defaultAddr    = "127.0.0.1:8080"
+	defaultTimeout = 5 * time.Second
+)
+
+const (
+	cfgAPIPrefix = "api"
+
+	cfgAPIEnabledTitle = cfgAPIPrefix + ".enabled"
+	cfgAPIAddrTitle    = cfgAPIPrefix + ".addr"
+	cfgAPITimeoutTitle = cfgAPIPrefix + ".timeout"
+)
+
+func NewConfig() *Config {
+	c := &Config{}
+
+	return c
+}
+
+func (c *Config) Validate() error {
+	if len(c.Addr) == 0 {
+		c.Addr = defaultAddr
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultTimeout
+	}
+
+	return nil
+}
+
+func (c *Config) Print() {
+	log.Print(cfgAPIEnabledTitle+": ", c.Enabled)
+
+	if !c.Enabled {
+		return
+	}
+
+	log.Print(cfgAPIAddrTitle+": ", c.Addr)
+	log.Print(cfgAPITimeoutTitle+": ", c.Timeout)
+}
@@ -5,12 +5,24 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"path/filepath"
 
 	"github.com/mitchellh/mapstructure"
 	"gopkg.in/yaml.v2"
 
+	"github.com/gibsn/duty_bot/internal/adminapi"
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
 	"github.com/gibsn/duty_bot/internal/dutyscheduler"
 	"github.com/gibsn/duty_bot/internal/productioncal"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+// StateStorage is where states of the projects are kept
+type StateStorage string
+
+const (
+	FileStateStorage   StateStorage = "file"   // a file per project
+	SQLiteStateStorage StateStorage = "sqlite" // a single SQLite database
 )
 
 type Config struct {
@@ -18,6 +30,9 @@ type Config struct {
 
 	Projects      []dutyscheduler.Config
 	ProductionCal productioncal.Config `mapstructure:"production_cal"`
+	API           adminapi.Config
+	StateDir      string       `mapstructure:"state_dir"`     // where states of projects are kept
+	StateStorage  StateStorage `mapstructure:"state_storage"` // how states of projects are kept
 }
 
 func NewConfig() (Config, error) {
@@ -48,7 +63,10 @@ func (cfg *Config) parseConfigFile() error {
 
 	decoder, err := mapstructure.NewDecoder(
 		&mapstructure.DecoderConfig{
-			DecodeHook:  mapstructure.StringToTimeDurationHookFunc(),
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				mapstructure.StringToTimeDurationHookFunc(),
+				dutyscheduler.ApplicantsDecodeHook(),
+			),
 			Result:      &cfg,
 			ErrorUnused: true,
 		},
@@ -74,6 +92,21 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("invalid production calendar config: %w", err)
 	}
 
+	if err := cfg.API.Validate(); err != nil {
+		return fmt.Errorf("invalid api config: %w", err)
+	}
+
+	switch cfg.StateStorage {
+	case "":
+		cfg.StateStorage = FileStateStorage
+	case FileStateStorage, SQLiteStateStorage:
+	default:
+		return fmt.Errorf(
+			"state_storage '%s' must be one of %s, %s: %w",
+			cfg.StateStorage, FileStateStorage, SQLiteStateStorage, cfgUtil.ErrNotSupported,
+		)
+	}
+
 	return nil
 }
 
@@ -87,4 +120,27 @@ func (cfg Config) Print() {
 
 	log.Printf("*** production calendar ***")
 	cfg.ProductionCal.Print()
+
+	log.Printf("*** api ***")
+	cfg.API.Print()
+
+	log.Printf("state_dir: %s", cfg.StateDir)
+	log.Printf("state_storage: %s", cfg.StateStorage)
+}
+
+// StateDBPath returns the path to the database states are kept in with SQLite storage
+func (cfg Config) StateDBPath() string {
+	return filepath.Join(cfg.StateDir, statedumper.SQLiteFileName)
+}
+
+// Countries returns the production calendars needed by the regions of the projects
+// besides the default one
+func (cfg Config) Countries() []string {
+	var countries []string
+
+	for _, project := range cfg.Projects {
+		countries = append(countries, project.Countries()...)
+	}
+
+	return countries
 }
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"syscall"
 
+	"github.com/gibsn/duty_bot/internal/adminapi"
 	"github.com/gibsn/duty_bot/internal/app/dutybot/cfg"
 	"github.com/gibsn/duty_bot/internal/dutyscheduler"
 	"github.com/gibsn/duty_bot/internal/productioncal"
@@ -20,8 +21,9 @@ type DutyBot struct {
 	cfg cfg.Config
 
 	schedulers    []*dutyscheduler.DutyScheduler
-	stateDumper   *statedumper.FileDumper
+	stateDumper   statedumper.StateDumper
 	productionCal *productioncal.ProductionCal
+	adminAPI      *adminapi.Server
 
 	shutdownOnce *sync.Once
 	finished     chan struct{}
@@ -45,7 +47,7 @@ func NewDutyBot(cfg cfg.Config) (*DutyBot, error) {
 
 	for _, projectCfg := range cfg.Projects {
 		sch, err := dutyscheduler.NewDutyScheduler(
-			projectCfg, bot.stateDumper, bot.productionCal,
+			projectCfg, bot.stateDumper, newDayOffsDB(bot.productionCal),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("could not init project '%s': %w", projectCfg.Name, err)
@@ -54,13 +56,21 @@ func NewDutyBot(cfg cfg.Config) (*DutyBot, error) {
 		bot.schedulers = append(bot.schedulers, sch)
 	}
 
+	if cfg.API.Enabled {
+		if err := bot.initAdminAPI(); err != nil {
+			return nil, fmt.Errorf("could not init admin api: %w", err)
+		}
+	}
+
 	go bot.signalHandler()
 
 	return bot, nil
 }
 
 func (bot *DutyBot) initProductionCal() {
-	bot.productionCal = productioncal.NewProductionCal(bot.cfg.ProductionCal)
+	bot.productionCal = productioncal.NewProductionCal(
+		bot.cfg.ProductionCal, bot.cfg.Countries()...,
+	)
 
 	if err := bot.productionCal.Init(); err != nil {
 		log.Printf("error: could not initialise production calendar: %v", err)
@@ -72,9 +82,8 @@ func (bot *DutyBot) initProductionCal() {
 	go bot.productionCal.Routine()
 }
 
-//nolint: unparam
 func (bot *DutyBot) initStateDumper() error {
-	stateDumper, err := statedumper.NewFileDumper()
+	stateDumper, err := newStateDumper(bot.cfg)
 	if err != nil {
 		return err
 	}
@@ -84,6 +93,12 @@ func (bot *DutyBot) initStateDumper() error {
 	return nil
 }
 
+func (bot *DutyBot) initAdminAPI() error {
+	bot.adminAPI = adminapi.NewServer(bot.cfg.API, bot.schedulers)
+
+	return bot.adminAPI.Start()
+}
+
 func (bot *DutyBot) signalHandler() {
 	signalQ := make(chan os.Signal, 1)
 	signal.Notify(signalQ, syscall.SIGTERM, syscall.SIGINT)
@@ -103,6 +118,12 @@ func (bot *DutyBot) Wait() {
 func (bot *DutyBot) Shutdown() {
 	log.Println("info: shutting down")
 
+	if bot.adminAPI != nil {
+		if err := bot.adminAPI.Shutdown(); err != nil {
+			log.Printf("error: could not shut down admin api: %v", err)
+		}
+	}
+
 	for _, sch := range bot.schedulers {
 		sch.Shutdown()
 	}
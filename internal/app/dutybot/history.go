@@ -0,0 +1,77 @@
+package dutybot
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/app/dutybot/cfg"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+const historyTimeLayout = "2006-01-02 15:04:05"
+
+// PrintHistory writes the duty history of the project with the given name (or of all
+// the projects if the name is empty) made in [from, till) to w, zero bounds are not
+// checked. The history is read from the configured state storage.
+func PrintHistory(config cfg.Config, projectName string, from, till time.Time, w io.Writer) error {
+	stateDumper, err := newStateDumper(config)
+	if err != nil {
+		return fmt.Errorf("could not init state dumper: %w", err)
+	}
+
+	defer stateDumper.Shutdown()
+
+	found := false
+
+	for _, projectCfg := range config.Projects {
+		if len(projectName) != 0 && projectCfg.Name != projectName {
+			continue
+		}
+
+		found = true
+
+		records, err := stateDumper.History(projectCfg.Name, from, till)
+		if err != nil {
+			return fmt.Errorf("could not read history of '%s': %w", projectCfg.Name, err)
+		}
+
+		if err := writeHistory(w, projectCfg.Name, records); err != nil {
+			return fmt.Errorf("could not write history: %w", err)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("could not find project '%s'", projectName)
+	}
+
+	return nil
+}
+
+func writeHistory(w io.Writer, projectName string, records []statedumper.HistoryRecord) error {
+	if _, err := fmt.Fprintf(w, "*** %s ***\n", projectName); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint: gomnd
+
+	for _, r := range records {
+		line := r.Time.Local().Format(historyTimeLayout) + "\t" + r.Person + "\t" + string(r.Reason)
+		if len(r.Region) != 0 {
+			line += "\t" + r.Region
+		}
+
+		if _, err := fmt.Fprintln(tw, line); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(w)
+
+	return err
+}
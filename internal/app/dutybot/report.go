@@ -0,0 +1,161 @@
+package dutybot
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/app/dutybot/cfg"
+	"github.com/gibsn/duty_bot/internal/dutyscheduler"
+	"github.com/gibsn/duty_bot/internal/productioncal"
+)
+
+// ReportFormat is the format of the duty report
+type ReportFormat string
+
+const (
+	CSVReportFormat  ReportFormat = "csv"
+	JSONReportFormat ReportFormat = "json"
+)
+
+var reportHeader = []string{"project", "person", "working_days", "weekends", "holidays", "total"}
+
+// WriteReport writes the duty every person has done in [from, till) in the project with
+// the given name (or in all the projects if the name is empty) to w in the given format.
+// The duty is counted by the history of the projects, days are split into working days,
+// weekends and public holidays by the production calendar fetched for the range.
+func WriteReport(
+	config cfg.Config, projectName string, from, till time.Time, format ReportFormat, w io.Writer,
+) error {
+	if format != CSVReportFormat && format != JSONReportFormat {
+		return fmt.Errorf("unknown report format '%s'", format)
+	}
+
+	var cal *productioncal.Archive
+
+	if config.ProductionCal.Enabled {
+		// time zones of the applicants may shift the dates by a day
+		var err error
+
+		cal, err = productioncal.FetchArchive(
+			config.ProductionCal, from.AddDate(0, 0, -1), till.AddDate(0, 0, 1),
+			config.Countries()...,
+		)
+		if err != nil {
+			return fmt.Errorf("could not fetch production calendar: %w", err)
+		}
+	} else {
+		log.Println("warning: production calendar is disabled, public holidays are not known")
+	}
+
+	stateDumper, err := newStateDumper(config)
+	if err != nil {
+		return fmt.Errorf("could not init state dumper: %w", err)
+	}
+
+	defer stateDumper.Shutdown()
+
+	var reports []dutyscheduler.DutyReport
+
+	found := false
+	now := time.Now()
+
+	for _, projectCfg := range config.Projects {
+		if len(projectName) != 0 && projectCfg.Name != projectName {
+			continue
+		}
+
+		found = true
+
+		// vacations do not matter for the duty that has already been done
+		project, err := dutyscheduler.NewStandaloneProject(
+			projectCfg, stateDumper, newArchiveDB(cal), false,
+		)
+		if err != nil {
+			return fmt.Errorf("could not init project '%s': %w", projectCfg.Name, err)
+		}
+
+		// the records before the range tell who was on duty when it started
+		history, err := stateDumper.History(projectCfg.Name, time.Time{}, till)
+		if err != nil {
+			return fmt.Errorf("could not read history of '%s': %w", projectCfg.Name, err)
+		}
+
+		projectReports, err := project.Report(history, from, till, now)
+		if err != nil {
+			return fmt.Errorf("could not make report of '%s': %w", projectCfg.Name, err)
+		}
+
+		reports = append(reports, projectReports...)
+	}
+
+	if !found {
+		return fmt.Errorf("could not find project '%s'", projectName)
+	}
+
+	if format == JSONReportFormat {
+		return writeJSONReport(w, reports)
+	}
+
+	return writeCSVReport(w, reports)
+}
+
+func writeCSVReport(w io.Writer, reports []dutyscheduler.DutyReport) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(reportHeader); err != nil {
+		return fmt.Errorf("could not write report: %w", err)
+	}
+
+	formatDays := func(days float64) string {
+		return strconv.FormatFloat(days, 'f', 2, 64) //nolint: gomnd
+	}
+
+	for _, r := range reports {
+		err := cw.Write([]string{
+			r.Project, r.Person,
+			formatDays(r.WorkingDays), formatDays(r.Weekends), formatDays(r.Holidays),
+			formatDays(r.Total),
+		})
+		if err != nil {
+			return fmt.Errorf("could not write report: %w", err)
+		}
+	}
+
+	cw.Flush()
+
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("could not write report: %w", err)
+	}
+
+	return nil
+}
+
+func writeJSONReport(w io.Writer, reports []dutyscheduler.DutyReport) error {
+	if reports == nil {
+		reports = []dutyscheduler.DutyReport{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(reports); err != nil {
+		return fmt.Errorf("could not write report: %w", err)
+	}
+
+	return nil
+}
+
+// newArchiveDB converts the production calendar archive to an interface that
+// is nil in case the calendar is disabled.
+func newArchiveDB(cal *productioncal.Archive) dayOffsDB {
+	if cal == nil {
+		return nil
+	}
+
+	return cal
+}
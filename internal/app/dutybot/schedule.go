@@ -0,0 +1,77 @@
+package dutybot
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/app/dutybot/cfg"
+	"github.com/gibsn/duty_bot/internal/dutyscheduler"
+	"github.com/gibsn/duty_bot/internal/productioncal"
+)
+
+type dayOffsDB interface {
+	IsDayOff(time.Time) (bool, error)
+}
+
+// PrintSchedule simulates the next n changes of the person of duty for the project
+// with the given name (or for all the projects if the name is empty) and writes
+// the result to w. State of the projects is restored from disk but is not changed.
+func PrintSchedule(config cfg.Config, projectName string, n int, w io.Writer) error {
+	var cal *productioncal.ProductionCal
+
+	if config.ProductionCal.Enabled {
+		cal = productioncal.NewProductionCal(config.ProductionCal, config.Countries()...)
+
+		if err := cal.Init(); err != nil {
+			log.Printf("error: could not initialise production calendar: %v", err)
+			log.Println("warning: only weekends will be considered as day offs")
+		}
+	}
+
+	stateDumper, err := newStateDumper(config)
+	if err != nil {
+		return fmt.Errorf("could not init state dumper: %w", err)
+	}
+
+	defer stateDumper.Shutdown()
+
+	found := false
+
+	for _, projectCfg := range config.Projects {
+		if len(projectName) != 0 && projectCfg.Name != projectName {
+			continue
+		}
+
+		found = true
+
+		project, err := dutyscheduler.NewStandaloneProject(
+			projectCfg, stateDumper, newDayOffsDB(cal), true,
+		)
+		if err != nil {
+			return fmt.Errorf("could not init project '%s': %w", projectCfg.Name, err)
+		}
+
+		_, err = fmt.Fprintf(w, "*** %s ***\n%s\n", project.Name(), project.FormatSchedule(n))
+		if err != nil {
+			return fmt.Errorf("could not write schedule: %w", err)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("could not find project '%s'", projectName)
+	}
+
+	return nil
+}
+
+// newDayOffsDB converts the production calendar to an interface that
+// is nil in case the calendar is disabled.
+func newDayOffsDB(cal *productioncal.ProductionCal) dayOffsDB {
+	if cal == nil {
+		return nil
+	}
+
+	return cal
+}
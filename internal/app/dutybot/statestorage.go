@@ -0,0 +1,125 @@
+package dutybot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gibsn/duty_bot/internal/app/dutybot/cfg"
+	"github.com/gibsn/duty_bot/internal/dutyscheduler"
+	"github.com/gibsn/duty_bot/internal/outbox"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+// newStateDumper creates the state dumper of the configured storage
+func newStateDumper(config cfg.Config) (statedumper.StateDumper, error) {
+	if config.StateStorage == cfg.SQLiteStateStorage {
+		return statedumper.NewSQLiteDumper(config.StateDBPath())
+	}
+
+	return statedumper.NewFileDumper(config.StateDir)
+}
+
+// MigrateStates copies states, outboxes and duty history of the projects from the files
+// in the state directory to the SQLite database there and writes a report to w. States
+// already present in the database are overwritten, history is copied only for the
+// projects that have none in the database yet. The files are left intact.
+func MigrateStates(config cfg.Config, w io.Writer) error {
+	states, err := statedumper.LoadStateFiles(config.StateDir)
+	if err != nil {
+		return fmt.Errorf("could not load state files: %w", err)
+	}
+
+	db, err := statedumper.NewSQLiteDumper(config.StateDBPath())
+	if err != nil {
+		return fmt.Errorf("could not open state database: %w", err)
+	}
+
+	defer db.Shutdown()
+
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := db.SaveState(states[name]); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "%s: state migrated\n", name); err != nil {
+			return fmt.Errorf("could not write report: %w", err)
+		}
+	}
+
+	for _, project := range config.Projects {
+		path := filepath.Join(config.StateDir, project.Name+dutyscheduler.OutboxFileExt)
+
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		entries, err := outbox.NewFileStore(path).Load()
+		if err != nil {
+			return fmt.Errorf("could not load outbox of '%s': %w", project.Name, err)
+		}
+
+		if err := db.OutboxStore(project.Name).Save(entries); err != nil {
+			return fmt.Errorf("could not migrate outbox of '%s': %w", project.Name, err)
+		}
+
+		_, err = fmt.Fprintf(w, "%s: %d undelivered updates migrated\n", project.Name, len(entries))
+		if err != nil {
+			return fmt.Errorf("could not write report: %w", err)
+		}
+	}
+
+	for _, project := range config.Projects {
+		if err := migrateHistory(config.StateDir, project.Name, db, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateHistory copies the history file of the project to the database unless
+// the database already has history of the project, so that it is never doubled
+func migrateHistory(dir, project string, db *statedumper.SQLiteDumper, w io.Writer) error {
+	records, err := statedumper.ReadHistoryFile(
+		filepath.Join(dir, project+statedumper.HistoryFileExt),
+	)
+	if err != nil {
+		return fmt.Errorf("could not load history of '%s': %w", project, err)
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	migrated, err := db.HasHistory(project)
+	if err != nil {
+		return err
+	}
+
+	report := fmt.Sprintf("%s: history is already in the database, skipping\n", project)
+
+	if !migrated {
+		if err := db.AppendHistory(project, records...); err != nil {
+			return fmt.Errorf("could not migrate history of '%s': %w", project, err)
+		}
+
+		report = fmt.Sprintf("%s: %d history records migrated\n", project, len(records))
+	}
+
+	if _, err := io.WriteString(w, report); err != nil {
+		return fmt.Errorf("could not write report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package atomicfile
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// BackupSuffix is appended to the name of a file to get the name of its previous version
+const BackupSuffix = ".bak"
+
+// BackupPath returns the path the previous version of the given file is kept at
+func BackupPath(path string) string {
+	return path + BackupSuffix
+}
+
+// Write replaces the file at the given path with data so that the file is never
+// left half-written: data is written to a temporary file in the same directory,
+// synced to disk and renamed over the file. The previous version of the file is
+// kept at BackupPath if backup is set.
+func Write(path string, data []byte, backup bool) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("could not create file: %w", err)
+	}
+
+	if err := writeAndSync(tmp, data); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if backup {
+		// the file is missing till the next rename, readers fall back to the backup then
+		if err := os.Rename(path, BackupPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			os.Remove(tmp.Name())
+			return fmt.Errorf("could not back up file: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("could not replace file: %w", err)
+	}
+
+	return syncDir(dir)
+}
+
+func writeAndSync(f *os.File, data []byte) error {
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write file: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("could not sync file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not close file: %w", err)
+	}
+
+	return nil
+}
+
+// syncDir makes the renames in the directory durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("could not open dir: %w", err)
+	}
+
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("could not sync dir: %w", err)
+	}
+
+	return nil
+}
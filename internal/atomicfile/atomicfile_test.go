@@ -0,0 +1,37 @@
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test_project.state")
+
+	assert.NoError(t, Write(path, []byte("v1"), true))
+
+	_, err := os.Stat(BackupPath(path))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	assert.NoError(t, Write(path, []byte("v2"), true))
+	assert.NoError(t, Write(path, []byte("v3"), true))
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "v3", string(data))
+
+	data, err = ioutil.ReadFile(BackupPath(path))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(data))
+
+	// no temporary files are left behind
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	assert.Error(t, Write(filepath.Join(path, "nested"), []byte("v1"), false))
+}
@@ -0,0 +1,267 @@
+package dutyscheduler
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+const (
+	applicantNameParamName        = "name"
+	applicantDisplayNameParamName = "display_name"
+	applicantAliasesParamName     = "aliases"
+	applicantWeightParamName      = "weight"
+	applicantTimezoneParamName    = "timezone"
+	applicantContactsParamName    = "contacts"
+	applicantRulesParamName       = "rules"
+	applicantActiveParamName      = "active"
+)
+
+const (
+	applicantsSeparator = ","
+
+	defaultApplicantWeight = 1
+)
+
+// ApplicantConfig describes a person taking duty in turn. The applicant is
+// identified by the name, aliases are the other names the person is known by,
+// e.g. in vacation calendars or chat commands.
+type ApplicantConfig struct {
+	prefix string
+
+	Name        string
+	DisplayName string `mapstructure:"display_name"`
+	Aliases     []string
+
+	Weight   float64 // relative share of duties
+	Timezone string  // dates of the applicant are checked in this time zone, local by default
+
+	Contacts ContactsConfig
+	Rules    RulesConfig // restrictions on the shifts the applicant takes
+
+	Active *bool // inactive applicants do not take duty, active by default
+}
+
+// Applicants is the list of duty applicants in the order they take duty.
+// In yaml it is either a list of applicants or their names joined by comma.
+type Applicants []ApplicantConfig
+
+// ParseApplicants parses names of the applicants joined by comma
+func ParseApplicants(s string) Applicants {
+	applicants := Applicants{}
+
+	for _, name := range strings.Split(s, applicantsSeparator) {
+		if name = strings.TrimSpace(name); len(name) != 0 {
+			applicants = append(applicants, ApplicantConfig{Name: name})
+		}
+	}
+
+	return applicants
+}
+
+// ApplicantsDecodeHook allows applicants to be given by their names joined by comma
+func ApplicantsDecodeHook() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(Applicants{}) {
+			return data, nil
+		}
+
+		applicants := ParseApplicants(data.(string))
+
+		// mapstructure decodes lists of maps into lists of structs
+		list := make([]map[string]interface{}, 0, len(applicants))
+		for _, applicant := range applicants {
+			list = append(list, map[string]interface{}{applicantNameParamName: applicant.Name})
+		}
+
+		return list, nil
+	}
+}
+
+// Names returns names of all the applicants
+func (applicants Applicants) Names() []string {
+	names := make([]string, 0, len(applicants))
+	for _, applicant := range applicants {
+		names = append(names, applicant.Name)
+	}
+
+	return names
+}
+
+func (applicants Applicants) String() string {
+	return strings.Join(applicants.Names(), applicantsSeparator)
+}
+
+func (cfg ApplicantConfig) paramWithPrefix() func(name string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
+
+func (cfg *ApplicantConfig) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	cfg.Name = strings.TrimSpace(cfg.Name)
+
+	if len(cfg.Name) == 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(applicantNameParamName), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+
+	if strings.Contains(cfg.Name, applicantsSeparator) {
+		return fmt.Errorf(
+			"%s '%s': must not contain '%s': %w",
+			paramNameFactory(applicantNameParamName), cfg.Name, applicantsSeparator,
+			cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	if cfg.Weight == 0 {
+		cfg.Weight = defaultApplicantWeight
+	}
+
+	if cfg.Weight < 0 {
+		return fmt.Errorf(
+			"%s: must be positive: %w",
+			paramNameFactory(applicantWeightParamName), cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+		return fmt.Errorf(
+			"%s '%s': %v: %w",
+			paramNameFactory(applicantTimezoneParamName), cfg.Timezone, err, cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	cfg.Contacts.SetPrefix(paramNameFactory(applicantContactsParamName))
+
+	if err := cfg.Contacts.Validate(); err != nil {
+		return err
+	}
+
+	cfg.Rules.SetPrefix(paramNameFactory(applicantRulesParamName))
+
+	if err := cfg.Rules.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.Active == nil {
+		active := true
+		cfg.Active = &active
+	}
+
+	return nil
+}
+
+func (cfg ApplicantConfig) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %s", paramNameFactory(applicantNameParamName), cfg.Name)
+	log.Printf("%s: %s", paramNameFactory(applicantDisplayNameParamName), cfg.DisplayName)
+	log.Printf("%s: %v", paramNameFactory(applicantAliasesParamName), cfg.Aliases)
+	log.Printf("%s: %g", paramNameFactory(applicantWeightParamName), cfg.Weight)
+	log.Printf("%s: %s", paramNameFactory(applicantTimezoneParamName), cfg.Timezone)
+	cfg.Contacts.Print()
+	cfg.Rules.Print()
+	log.Printf("%s: %t", paramNameFactory(applicantActiveParamName), cfg.IsActive())
+}
+
+func (cfg *ApplicantConfig) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
+
+// IsActive reports whether the applicant takes duty
+func (cfg ApplicantConfig) IsActive() bool {
+	return cfg.Active == nil || *cfg.Active
+}
+
+// location returns the time zone of the applicant
+func (cfg ApplicantConfig) location() *time.Location {
+	if len(cfg.Timezone) == 0 {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return time.Local
+	}
+
+	return loc
+}
+
+// person returns the profile of the applicant
+func (cfg ApplicantConfig) person() notifychannel.Person {
+	return notifychannel.Person{
+		Name:        cfg.Name,
+		DisplayName: cfg.DisplayName,
+		MyTeam:      cfg.Contacts.MyTeam,
+		Slack:       cfg.Contacts.Slack,
+		Telegram:    cfg.Contacts.Telegram,
+		Email:       cfg.Contacts.Email,
+	}
+}
+
+// validateApplicants validates every applicant, names and aliases must be unique.
+// Profiles are applied to the applicants they belong to.
+func (cfg *Config) validateApplicants() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	if len(cfg.Applicants) == 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(applicantsParamName), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+
+	known := make(map[string]bool)
+	active := 0
+
+	for i := range cfg.Applicants {
+		applicant := &cfg.Applicants[i]
+		applicant.SetPrefix(fmt.Sprintf("%s[%d]", paramNameFactory(applicantsParamName), i))
+
+		if err := applicant.Validate(); err != nil {
+			return err
+		}
+
+		for _, name := range append([]string{applicant.Name}, applicant.Aliases...) {
+			if known[name] {
+				return fmt.Errorf(
+					"%s: '%s' is listed twice: %w",
+					paramNameFactory(applicantsParamName), name, cfgUtil.ErrInvalidValue,
+				)
+			}
+
+			known[name] = true
+		}
+
+		if applicant.IsActive() {
+			active++
+		}
+	}
+
+	if active == 0 {
+		return fmt.Errorf(
+			"%s: no active applicants: %w",
+			paramNameFactory(applicantsParamName), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+
+	return cfg.validateProfiles()
+}
+
+func (cfg Config) printApplicants() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %s", paramNameFactory(applicantsParamName), cfg.Applicants)
+
+	for _, applicant := range cfg.Applicants {
+		applicant.Print()
+	}
+}
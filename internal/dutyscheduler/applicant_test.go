@@ -0,0 +1,141 @@
+package dutyscheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/cfg"
+)
+
+func TestParseApplicants(t *testing.T) {
+	assert.Equal(t, []string{"test1", "test2", "test3"}, ParseApplicants(" test1, test2 ,,test3,").Names())
+	assert.Empty(t, ParseApplicants(""))
+}
+
+func TestApplicantsDecodeHook(t *testing.T) {
+	decode := func(raw map[string]interface{}) Config {
+		config := Config{}
+
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:  ApplicantsDecodeHook(),
+			Result:      &config,
+			ErrorUnused: true,
+		})
+		if err != nil {
+			t.Fatalf("could not create decoder: %v", err)
+		}
+
+		assert.NoError(t, decoder.Decode(raw))
+
+		return config
+	}
+
+	config := decode(map[string]interface{}{"applicants": "test1, test2"})
+	assert.Equal(t, ParseApplicants("test1,test2"), config.Applicants)
+
+	config = decode(map[string]interface{}{
+		"applicants": []interface{}{
+			map[interface{}]interface{}{
+				"name":     "test1",
+				"aliases":  []interface{}{"Test One"},
+				"weight":   0.5,
+				"timezone": "Asia/Tokyo",
+				"contacts": map[interface{}]interface{}{"slack": "U1"},
+			},
+			map[interface{}]interface{}{"name": "test2", "active": false},
+		},
+	})
+
+	if assert.Len(t, config.Applicants, 2) {
+		assert.Equal(t, []string{"Test One"}, config.Applicants[0].Aliases)
+		assert.Equal(t, 0.5, config.Applicants[0].Weight)
+		assert.Equal(t, "Asia/Tokyo", config.Applicants[0].Timezone)
+		assert.Equal(t, "U1", config.Applicants[0].Contacts.Slack)
+		assert.True(t, config.Applicants[0].IsActive())
+		assert.False(t, config.Applicants[1].IsActive())
+	}
+}
+
+func TestConfigValidateApplicants(t *testing.T) {
+	inactive := false
+
+	invalid := []Applicants{
+		{},
+		{{Name: " "}},
+		{{Name: "test1"}, {Name: "test1"}},
+		{{Name: "test1", Aliases: []string{"test2"}}, {Name: "test2"}},
+		{{Name: "test1", Weight: -1}},
+		{{Name: "test1", Timezone: "Mars/Olympus"}},
+		{{Name: "test1", Contacts: ContactsConfig{Email: "test1"}}},
+		{{Name: "test1", Active: &inactive}},
+	}
+
+	for _, applicants := range invalid {
+		config := Config{Name: "test_project", Applicants: applicants}
+		assert.Error(t, config.Validate(), "%+v", applicants)
+	}
+
+	config := Config{
+		Name:       "test_project",
+		Applicants: Applicants{{Name: " test1 "}, {Name: "test2", Active: &inactive}},
+	}
+
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, "test1", config.Applicants[0].Name)
+	assert.Equal(t, float64(defaultApplicantWeight), config.Applicants[0].Weight)
+
+	config.Profiles = map[string]ProfileConfig{"test3": {}}
+	assert.ErrorIs(t, config.Validate(), cfg.ErrInvalidValue)
+}
+
+type calendarVacationDB map[string]bool // "name YYYY-MM-DD" -> on vacation
+
+func (db calendarVacationDB) IsOnVacation(person string, date time.Time) (bool, error) {
+	return db[person+" "+date.Format("2006-01-02")], nil
+}
+
+func TestProjectApplicants(t *testing.T) {
+	inactive := false
+
+	config := Config{
+		Name: "test_project",
+		Applicants: Applicants{
+			{Name: "test1", Aliases: []string{"one"}},
+			{Name: "test2", Active: &inactive},
+			{Name: "test3", Aliases: []string{"three"}, Timezone: "Asia/Tokyo"},
+		},
+		Period: string(EveryDay),
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	project, err := NewProjectFromConfig(config)
+	if err != nil {
+		t.Fatalf("could not create project: %v", err)
+	}
+
+	// inactive applicants do not take duty
+	assert.Equal(t, 2, project.ApplicantsCount())
+	assert.Equal(t, "test1", project.NextPerson())
+	assert.Equal(t, "test3", project.NextPerson())
+
+	assert.ErrorIs(t, project.Skip("test2"), ErrUnknownApplicant)
+
+	// aliases are resolved to names
+	assert.NoError(t, project.Swap("one", "three"))
+	assert.Equal(t, "test3", project.NextPerson())
+
+	// vacations are looked up by aliases in the time zone of the applicant,
+	// it is already the next day in Tokyo
+	now := time.Date(2022, time.March, 1, 20, 0, 0, 0, time.UTC)
+	project.SetVacationDB(calendarVacationDB{"three 2022-03-02": true})
+
+	assert.True(t, project.isOnVacation("test3", now))
+	assert.False(t, project.isOnVacation("test3", now.Add(-12*time.Hour)))
+	assert.False(t, project.isOnVacation("test1", now))
+}
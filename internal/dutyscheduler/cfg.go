@@ -7,17 +7,27 @@ import (
 	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
 	"github.com/gibsn/duty_bot/internal/notifychannel"
 	"github.com/gibsn/duty_bot/internal/notifychannel/myteam"
+	"github.com/gibsn/duty_bot/internal/notifychannel/slack"
+	"github.com/gibsn/duty_bot/internal/notifychannel/smtp"
+	"github.com/gibsn/duty_bot/internal/notifychannel/telegram"
+	"github.com/gibsn/duty_bot/internal/notifychannel/webhook"
 	vacationdb "github.com/gibsn/duty_bot/internal/vacationdb"
 )
 
 const (
 	applicantsParamName  = "applicants"
+	profilesParamName    = "profiles"
+	rolesParamName       = "roles"
+	regionsParamName     = "regions"
 	messageParamName     = "message"
 	periodParamName      = "period"
 	skipDayOffsParamName = "skip_dayoffs"
 	channelParamName     = "channel"
+	channelsParamName    = "channels"
 	persistParamName     = "persist"
+	fairnessParamName    = "fairness"
 	vacationParamName    = "vacation"
+	remindersParamName   = "reminders"
 )
 
 const (
@@ -28,18 +38,32 @@ const (
 type Config struct {
 	Name string
 
-	Applicants     string
-	MessagePattern string `mapstructure:"message"`
+	Applicants     Applicants
+	Profiles       map[string]ProfileConfig // applicant name -> profile
+	Roles          []RoleConfig             // roles besides the primary one
+	MessagePattern string                   `mapstructure:"message"`
 
+	Regions     []RegionConfig // if set, the regions follow the sun and period is not used
 	Period      string
 	SkipDayOffs bool `mapstructure:"skip_dayoffs"`
+	Fairness    bool // the next person is the one with less duty done instead of round robin
 
 	Vacation vacationdb.Config
 
-	Channel string
+	Reminders []ReminderConfig
+
 	Persist bool
 
-	MyTeam myteam.Config `mapstructure:"myteam"`
+	Channels []ChannelConfig
+
+	// a single channel configured with the blocks below, kept for compatibility,
+	// turned into Channels by Validate
+	Channel  string
+	MyTeam   myteam.Config   `mapstructure:"myteam"`
+	Slack    slack.Config    `mapstructure:"slack"`
+	Telegram telegram.Config `mapstructure:"telegram"`
+	Webhook  webhook.Config  `mapstructure:"webhook"`
+	SMTP     smtp.Config     `mapstructure:"smtp"`
 }
 
 func NewConfig() Config {
@@ -55,10 +79,24 @@ func (cfg Config) paramWithPrefix() func(name string) string {
 func (cfg *Config) Validate() error {
 	paramNameFactory := cfg.paramWithPrefix()
 
-	if len(cfg.Applicants) == 0 {
-		return fmt.Errorf(
-			"%s: %w", paramNameFactory(applicantsParamName), cfgUtil.ErrMustNotBeEmpty,
-		)
+	if err := cfg.validateApplicants(); err != nil {
+		return err
+	}
+
+	if err := cfg.validateRoles(); err != nil {
+		return err
+	}
+
+	if err := cfg.validateRegions(); err != nil {
+		return err
+	}
+
+	if len(cfg.MessagePattern) == 0 {
+		cfg.MessagePattern = defaultMessagePattern
+	}
+
+	if _, err := newMessageTemplate(cfg.MessagePattern); err != nil {
+		return fmt.Errorf("%s: %w", paramNameFactory(messageParamName), err)
 	}
 
 	if len(cfg.Period) == 0 {
@@ -69,19 +107,16 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("%s '%s': %w", paramNameFactory(periodParamName), cfg.Period, err)
 	}
 
-	switch notifychannel.Type(cfg.Channel) {
-	case notifychannel.MyTeamChannelType:
-		if err := cfg.MyTeam.Validate(); err != nil {
-			return fmt.Errorf("invalid myteam config: %w", err)
-		}
-
-		cfg.MyTeam.SetPrefix(cfg.Name)
-	case "":
-		cfg.Channel = string(defaultNotifyChannel)
+	if err := cfg.validateChannels(); err != nil {
+		return err
 	}
 
-	if err := notifychannel.Type(cfg.Channel).Validate(); err != nil {
-		return fmt.Errorf("%s '%s': %w", paramNameFactory(channelParamName), cfg.Channel, err)
+	for i := range cfg.Reminders {
+		cfg.Reminders[i].SetPrefix(fmt.Sprintf("%s[%d]", paramNameFactory(remindersParamName), i))
+
+		if err := cfg.Reminders[i].Validate(); err != nil {
+			return err
+		}
 	}
 
 	if err := cfg.Vacation.Validate(); err != nil {
@@ -94,20 +129,106 @@ func (cfg *Config) Validate() error {
 func (cfg *Config) Print() {
 	paramNameFactory := cfg.paramWithPrefix()
 
-	log.Printf("%s: %s", paramNameFactory(applicantsParamName), cfg.Applicants)
+	cfg.printApplicants()
+	cfg.printProfiles()
+
+	for _, role := range cfg.Roles {
+		role.Print()
+	}
 	log.Printf("%s: %s", paramNameFactory(messageParamName), cfg.MessagePattern)
+
+	for _, region := range cfg.Regions {
+		region.Print()
+	}
 	log.Printf("%s: %s", paramNameFactory(periodParamName), cfg.Period)
 	log.Printf("%s: %t", paramNameFactory(skipDayOffsParamName), cfg.SkipDayOffs)
-	log.Printf("%s: %s", paramNameFactory(channelParamName), cfg.Channel)
+	log.Printf("%s: %t", paramNameFactory(fairnessParamName), cfg.Fairness)
 	log.Printf("%s: %t", paramNameFactory(persistParamName), cfg.Persist)
 
-	if notifychannel.Type(cfg.Channel) == notifychannel.MyTeamChannelType {
-		cfg.MyTeam.Print()
+	if len(cfg.Channel) != 0 {
+		log.Printf("%s: %s", paramNameFactory(channelParamName), cfg.Channel)
+
+		for _, channel := range cfg.Channels {
+			channel.printSettings()
+		}
+	} else {
+		for _, channel := range cfg.Channels {
+			channel.Print()
+		}
+	}
+
+	for _, reminder := range cfg.Reminders {
+		reminder.Print()
 	}
 
 	cfg.Vacation.Print(cfg.Name + "." + vacationParamName)
 }
 
+// validateChannels validates every notification channel of the project. The
+// legacy single channel is turned into a list of one channel.
+func (cfg *Config) validateChannels() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	if len(cfg.Channels) != 0 {
+		if len(cfg.Channel) != 0 {
+			return fmt.Errorf(
+				"%s and %s are mutually exclusive: %w",
+				paramNameFactory(channelParamName), paramNameFactory(channelsParamName),
+				cfgUtil.ErrInvalidValue,
+			)
+		}
+
+		names := make(map[string]bool, len(cfg.Channels))
+
+		for i := range cfg.Channels {
+			cfg.Channels[i].SetPrefix(fmt.Sprintf("%s[%d]", paramNameFactory(channelsParamName), i))
+
+			if err := cfg.Channels[i].Validate(); err != nil {
+				return err
+			}
+
+			if name := cfg.Channels[i].Name; len(name) != 0 {
+				if names[name] {
+					return fmt.Errorf(
+						"%s: duplicate channel name '%s': %w",
+						paramNameFactory(channelsParamName), name, cfgUtil.ErrInvalidValue,
+					)
+				}
+
+				names[name] = true
+			}
+		}
+
+		return nil
+	}
+
+	if len(cfg.Channel) == 0 {
+		cfg.Channel = string(defaultNotifyChannel)
+	}
+
+	if err := notifychannel.Type(cfg.Channel).Validate(); err != nil {
+		return fmt.Errorf("%s '%s': %w", paramNameFactory(channelParamName), cfg.Channel, err)
+	}
+
+	channel := ChannelConfig{
+		Type:     cfg.Channel,
+		MyTeam:   cfg.MyTeam,
+		Slack:    cfg.Slack,
+		Telegram: cfg.Telegram,
+		Webhook:  cfg.Webhook,
+		SMTP:     cfg.SMTP,
+	}
+	channel.SetPrefix(cfg.Name)
+
+	if err := channel.Validate(); err != nil {
+		return err
+	}
+
+	cfg.Channels = []ChannelConfig{channel}
+
+	return nil
+}
+
 // StatePersistenceEnabled reports whether any project has state persistence enabled
 func (cfg Config) StatePersistenceEnabled() bool {
 	return cfg.Persist
@@ -0,0 +1,115 @@
+package dutyscheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+func TestConfigValidateChannels(t *testing.T) {
+	// legacy single channel
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2"),
+		Channel:    string(notifychannel.SlackChannelType),
+	}
+	config.Slack.WebhookURL = "https://hooks.slack.com/services/T/B/X"
+
+	assert.NoError(t, config.Validate())
+
+	if assert.Len(t, config.Channels, 1) {
+		assert.Equal(t, "slack", config.Channels[0].Type)
+		assert.Equal(t, config.Slack.WebhookURL, config.Channels[0].Slack.WebhookURL)
+		assert.Equal(t, defaultChannelMaxAge, config.Channels[0].MaxAge)
+	}
+
+	// no channel at all
+	config = Config{Name: "test_project", Applicants: ParseApplicants("test1,test2")}
+
+	assert.NoError(t, config.Validate())
+
+	if assert.Len(t, config.Channels, 1) {
+		assert.Equal(t, string(defaultNotifyChannel), config.Channels[0].Type)
+	}
+
+	// list of channels
+	config = Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2"),
+		Channels: []ChannelConfig{
+			{Type: "stdout", MaxAge: time.Hour},
+			{Type: "webhook"},
+		},
+	}
+	config.Channels[1].Webhook.URL = "https://example.com/hook"
+
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, time.Hour, config.Channels[0].MaxAge)
+	assert.Equal(t, defaultChannelRetryInterval, config.Channels[1].RetryInterval)
+
+	config.Channels[1].MaxRetryInterval = time.Second
+	assert.ErrorIs(t, config.Validate(), cfgUtil.ErrInvalidValue)
+
+	config.Channels[1].MaxRetryInterval = 0
+
+	// settings of every channel are validated
+	config.Channels = append(config.Channels, ChannelConfig{Type: "telegram"})
+	assert.ErrorIs(t, config.Validate(), cfgUtil.ErrMustNotBeEmpty)
+
+	config.Channels[2] = ChannelConfig{Type: "carrier pigeon"}
+	assert.ErrorIs(t, config.Validate(), cfgUtil.ErrNotSupported)
+
+	// both ways of configuring channels at once
+	config.Channels = config.Channels[:2]
+	config.Channel = "stdout"
+	assert.ErrorIs(t, config.Validate(), cfgUtil.ErrInvalidValue)
+}
+
+func TestChannelConfigID(t *testing.T) {
+	hook1 := ChannelConfig{Type: "webhook"}
+	hook1.Webhook.URL = "https://example.com/hook1"
+
+	hook2 := ChannelConfig{Type: "webhook"}
+	hook2.Webhook.URL = "https://example.com/hook2"
+
+	assert.NotEqual(t, hook1.ID(), hook2.ID())
+	assert.Regexp(t, `^webhook\[[0-9a-f]{8}\]$`, hook1.ID())
+	assert.Equal(t, "stdout", ChannelConfig{Type: "stdout"}.ID())
+
+	// the id does not depend on the position or on settings other than the destination
+	moved := hook1
+	moved.SetPrefix("test_project.channels[5]")
+	moved.MaxAge = time.Minute
+	moved.Webhook.Timeout = time.Minute
+	assert.Equal(t, hook1.ID(), moved.ID())
+
+	hook1.Name = "alerts"
+	assert.Equal(t, "alerts", hook1.ID())
+
+	// names must be unique
+	hook2.Name = "alerts"
+
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2"),
+		Channels:   []ChannelConfig{hook1, hook2},
+	}
+	assert.ErrorIs(t, config.Validate(), cfgUtil.ErrInvalidValue)
+}
+
+func TestConfigValidateMessage(t *testing.T) {
+	config := Config{Name: "test_project", Applicants: ParseApplicants("test1,test2")}
+
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, defaultMessagePattern, config.MessagePattern)
+
+	config.MessagePattern = "%s is on duty, %s is next"
+	assert.ErrorIs(t, config.Validate(), cfgUtil.ErrInvalidValue)
+
+	config.MessagePattern = "{{.Current}} is on duty, {{.Nxt}} is next"
+	assert.ErrorIs(t, config.Validate(), cfgUtil.ErrInvalidValue)
+}
@@ -0,0 +1,195 @@
+package dutyscheduler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+	"github.com/gibsn/duty_bot/internal/notifychannel/myteam"
+	"github.com/gibsn/duty_bot/internal/notifychannel/slack"
+	"github.com/gibsn/duty_bot/internal/notifychannel/smtp"
+	"github.com/gibsn/duty_bot/internal/notifychannel/telegram"
+	"github.com/gibsn/duty_bot/internal/notifychannel/webhook"
+)
+
+const (
+	channelNameParamName             = "name"
+	channelTypeParamName             = "type"
+	channelRetryIntervalParamName    = "retry_interval"
+	channelMaxRetryIntervalParamName = "max_retry_interval"
+	channelMaxAgeParamName           = "max_age"
+)
+
+const (
+	defaultChannelRetryInterval    = 5 * time.Second
+	defaultChannelMaxRetryInterval = 10 * time.Minute
+	defaultChannelMaxAge           = 24 * time.Hour
+
+	channelIDHashLen = 8
+)
+
+// ChannelConfig configures one of the notification channels of a project.
+// Only the settings block matching the type is used.
+type ChannelConfig struct {
+	prefix string
+
+	Name string // identifies undelivered updates of the channel, derived from settings if empty
+	Type string
+
+	// failed notifications are retried with exponential backoff starting from
+	// RetryInterval until they are older than MaxAge
+	RetryInterval    time.Duration `mapstructure:"retry_interval"`
+	MaxRetryInterval time.Duration `mapstructure:"max_retry_interval"`
+	MaxAge           time.Duration `mapstructure:"max_age"`
+
+	MyTeam   myteam.Config   `mapstructure:"myteam"`
+	Slack    slack.Config    `mapstructure:"slack"`
+	Telegram telegram.Config `mapstructure:"telegram"`
+	Webhook  webhook.Config  `mapstructure:"webhook"`
+	SMTP     smtp.Config     `mapstructure:"smtp"`
+}
+
+func (cfg ChannelConfig) paramWithPrefix() func(name string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
+
+func (cfg *ChannelConfig) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	if len(cfg.Type) == 0 {
+		cfg.Type = string(defaultNotifyChannel)
+	}
+
+	if err := notifychannel.Type(cfg.Type).Validate(); err != nil {
+		return fmt.Errorf("%s '%s': %w", paramNameFactory(channelTypeParamName), cfg.Type, err)
+	}
+
+	if cfg.RetryInterval == 0 {
+		cfg.RetryInterval = defaultChannelRetryInterval
+	}
+	if cfg.MaxRetryInterval == 0 {
+		cfg.MaxRetryInterval = defaultChannelMaxRetryInterval
+	}
+	if cfg.MaxAge == 0 {
+		cfg.MaxAge = defaultChannelMaxAge
+	}
+
+	if cfg.RetryInterval < 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(channelRetryIntervalParamName), cfgUtil.ErrInvalidValue,
+		)
+	}
+	if cfg.MaxRetryInterval < cfg.RetryInterval {
+		return fmt.Errorf(
+			"%s: must not be less than %s: %w",
+			paramNameFactory(channelMaxRetryIntervalParamName),
+			paramNameFactory(channelRetryIntervalParamName),
+			cfgUtil.ErrInvalidValue,
+		)
+	}
+	if cfg.MaxAge < 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(channelMaxAgeParamName), cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	var err error
+
+	switch notifychannel.Type(cfg.Type) {
+	case notifychannel.MyTeamChannelType:
+		cfg.MyTeam.SetPrefix(cfg.prefix)
+		err = cfg.MyTeam.Validate()
+	case notifychannel.SlackChannelType:
+		cfg.Slack.SetPrefix(cfg.prefix)
+		err = cfg.Slack.Validate()
+	case notifychannel.TelegramChannelType:
+		cfg.Telegram.SetPrefix(cfg.prefix)
+		err = cfg.Telegram.Validate()
+	case notifychannel.WebhookChannelType:
+		cfg.Webhook.SetPrefix(cfg.prefix)
+		err = cfg.Webhook.Validate()
+	case notifychannel.SMTPChannelType:
+		cfg.SMTP.SetPrefix(cfg.prefix)
+		err = cfg.SMTP.Validate()
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s config: %w", cfg.Type, err)
+	}
+
+	return nil
+}
+
+func (cfg ChannelConfig) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %s", paramNameFactory(channelNameParamName), cfg.Name)
+	log.Printf("%s: %s", paramNameFactory(channelTypeParamName), cfg.Type)
+
+	cfg.printSettings()
+}
+
+// printSettings prints everything but the type of the channel
+func (cfg ChannelConfig) printSettings() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %s", paramNameFactory(channelRetryIntervalParamName), cfg.RetryInterval)
+	log.Printf("%s: %s", paramNameFactory(channelMaxRetryIntervalParamName), cfg.MaxRetryInterval)
+	log.Printf("%s: %s", paramNameFactory(channelMaxAgeParamName), cfg.MaxAge)
+
+	switch notifychannel.Type(cfg.Type) {
+	case notifychannel.MyTeamChannelType:
+		cfg.MyTeam.Print()
+	case notifychannel.SlackChannelType:
+		cfg.Slack.Print()
+	case notifychannel.TelegramChannelType:
+		cfg.Telegram.Print()
+	case notifychannel.WebhookChannelType:
+		cfg.Webhook.Print()
+	case notifychannel.SMTPChannelType:
+		cfg.SMTP.Print()
+	}
+}
+
+// ID returns the identifier undelivered updates of the channel are saved with. It is
+// the name of the channel if set, otherwise it is derived from the type and the
+// destination of the channel, so that it does not depend on the order of channels
+// and updates are never delivered to a destination they were not rendered for.
+func (cfg ChannelConfig) ID() string {
+	if len(cfg.Name) != 0 {
+		return cfg.Name
+	}
+
+	var destination []string
+
+	switch notifychannel.Type(cfg.Type) {
+	case notifychannel.MyTeamChannelType:
+		destination = []string{cfg.MyTeam.APIURL, cfg.MyTeam.ChatID}
+	case notifychannel.SlackChannelType:
+		destination = []string{cfg.Slack.WebhookURL, cfg.Slack.APIURL, cfg.Slack.ChannelID}
+	case notifychannel.TelegramChannelType:
+		destination = []string{cfg.Telegram.APIURL, cfg.Telegram.ChatID}
+	case notifychannel.WebhookChannelType:
+		destination = []string{cfg.Webhook.URL}
+	case notifychannel.SMTPChannelType:
+		destination = append(
+			[]string{cfg.SMTP.Host, strconv.Itoa(cfg.SMTP.Port)}, cfg.SMTP.To...,
+		)
+	default:
+		return cfg.Type
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(destination, "\n")))
+
+	return fmt.Sprintf("%s[%s]", cfg.Type, hex.EncodeToString(sum[:])[:channelIDHashLen])
+}
+
+func (cfg *ChannelConfig) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
@@ -0,0 +1,109 @@
+package dutyscheduler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+const (
+	dutyCommand     = "/duty"
+	nextCommand     = "/next"
+	scheduleCommand = "/schedule"
+	swapCommand     = "/swap"
+	handOffCommand  = "/handoff"
+	statsCommand    = "/stats"
+)
+
+const (
+	maxScheduleLen = 31
+)
+
+type commandsServer interface {
+	ServeCommands(notifychannel.CommandHandler)
+}
+
+// serveCommands subscribes the scheduler to the commands sent to the notification
+// channels supporting it.
+func (sch *DutyScheduler) serveCommands() {
+	sch.mu.RLock()
+	defer sch.mu.RUnlock()
+
+	for _, ch := range sch.channels {
+		if server, ok := ch.notifyChannel.(commandsServer); ok {
+			server.ServeCommands(sch.HandleCommand)
+		}
+	}
+}
+
+// HandleCommand answers the given chat command about the project's rotation.
+func (sch *DutyScheduler) HandleCommand(cmd string, args []string) (string, error) {
+	sch.logger.Infof("received command %s %v", cmd, args)
+
+	switch cmd {
+	case dutyCommand:
+		reply := fmt.Sprintf("%s is on duty", sch.currentPersonOrNobody())
+
+		if region := sch.project.Region(); len(region) != 0 {
+			reply += fmt.Sprintf(" [%s]", region)
+		}
+
+		if roles := sch.project.Roles(); len(roles) != 0 {
+			reply += fmt.Sprintf(" (%s)", formatRoles(roles))
+		}
+
+		return reply, nil
+	case nextCommand:
+		schedule := sch.project.Schedule(1)
+		if len(schedule) == 0 {
+			return "no changes are scheduled", nil
+		}
+
+		return fmt.Sprintf(
+			"%s (in %s)",
+			schedule[0], time.Until(schedule[0].Start).Round(time.Second),
+		), nil
+	case scheduleCommand:
+		return sch.handleScheduleCommand(args)
+	case swapCommand:
+		if len(args) != 2 { // nolint: gomnd
+			return "", fmt.Errorf("usage: %s person1 person2: %w", swapCommand, cfg.ErrInvalidValue)
+		}
+
+		return "", sch.Swap(args[0], args[1])
+	case handOffCommand:
+		if len(args) != 1 {
+			return "", fmt.Errorf("usage: %s person: %w", handOffCommand, cfg.ErrInvalidValue)
+		}
+
+		return "", sch.HandOff(args[0])
+	case statsCommand:
+		return sch.project.FormatStats(), nil
+	}
+
+	return "", notifychannel.ErrUnknownCommand
+}
+
+func (sch *DutyScheduler) handleScheduleCommand(args []string) (string, error) {
+	n := sch.project.ApplicantsCount()
+
+	if len(args) > 0 {
+		var err error
+
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n <= 0 || n > maxScheduleLen {
+			return "", fmt.Errorf(
+				"number of changes must be in range [1, %d]: %w", maxScheduleLen, cfg.ErrInvalidValue,
+			)
+		}
+	}
+
+	return sch.project.FormatSchedule(n), nil
+}
+
+func (sch *DutyScheduler) currentPersonOrNobody() string {
+	return sch.project.currentPersonOrNobody()
+}
@@ -2,27 +2,62 @@ package dutyscheduler
 
 import (
 	"fmt"
+	"path/filepath"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/gibsn/duty_bot/internal/cfg"
 	"github.com/gibsn/duty_bot/internal/notifychannel"
 	"github.com/gibsn/duty_bot/internal/notifychannel/myteam"
+	"github.com/gibsn/duty_bot/internal/notifychannel/slack"
+	"github.com/gibsn/duty_bot/internal/notifychannel/smtp"
+	"github.com/gibsn/duty_bot/internal/notifychannel/telegram"
+	"github.com/gibsn/duty_bot/internal/notifychannel/webhook"
+	"github.com/gibsn/duty_bot/internal/outbox"
 	"github.com/gibsn/duty_bot/internal/statedumper"
 	"github.com/gibsn/duty_bot/internal/vacationdb"
 )
 
+const (
+	// OutboxFileExt is the extension of files keeping outboxes of the projects
+	OutboxFileExt = ".outbox"
+)
+
 type stateDumper interface {
 	Dump(statedumper.Dumpable) error
 	GetState(string) (statedumper.SchedulingState, error)
 }
 
+// stateDirDumper keeps states in a directory on disk, the outbox is kept there too
+type stateDirDumper interface {
+	Dir() string
+}
+
+// outboxDumper keeps outboxes of the projects along with their states
+type outboxDumper interface {
+	OutboxStore(project string) outbox.Store
+}
+
 type notifyChannel interface {
 	Send(string) error
 	Shutdown() error
 }
 
+// notificationSender is implemented by notification channels that make use
+// of the details of an update besides its text.
+type notificationSender interface {
+	SendNotification(notifychannel.Notification) error
+}
+
+// mentioner is implemented by notification channels that are able to
+// mention persons, an empty mention means the person can not be mentioned.
+type mentioner interface {
+	Mention(notifychannel.Person) string
+}
+
 // DutyScheduler schedules persons of duty in given periods of time.
 // On any change it sends a notification to the given communication channel.
 type DutyScheduler struct {
@@ -32,21 +67,40 @@ type DutyScheduler struct {
 
 	project *Project
 
-	eventsQ       chan Event
-	notifyChannel notifyChannel // a communication channel to send updates to (like myteam)
+	eventsQ  chan Event
+	channels []*channel     // communication channels to send updates to (like myteam)
+	outbox   *outbox.Outbox // notifications that have not been delivered yet
 
 	stateDumper stateDumper
 
 	shutdownOnce *sync.Once
 	shutdownInit chan struct{}
 
-	eventsFinished chan struct{}
-	mu             *sync.RWMutex
+	rescheduleQ chan struct{} // wakes up events routine to recalculate the next change
+
+	reminders         []*reminder
+	remindersQ        chan struct{} // wakes up reminders routine to recalculate the next reminder
+	remindersFinished *sync.WaitGroup
+
+	eventsFinished   chan struct{}
+	deliveryFinished *sync.WaitGroup
+	mu               *sync.RWMutex
+	changeMu         *sync.Mutex // serialises scheduled and forced changes
 }
 
 // Event represents a change for a given project
 type Event struct {
-	newPerson string
+	newPerson  string
+	prevPerson string
+	roles      map[string]string
+	nextChange time.Time
+
+	// the message is rendered with data for every channel separately so that
+	// persons are mentioned the way the channel supports, text is sent as is
+	// if there is no message
+	message *template.Template
+	data    MessageData
+	text    string
 }
 
 // NewDutyScheduler creates a new DutyScheduler and starts an event
@@ -63,6 +117,10 @@ func NewDutyScheduler(
 
 	go sch.eventsRoutine()
 	go sch.notificaionSenderRoutine()
+	sch.startDeliveryRoutines()
+	sch.startRemindersRoutine()
+
+	sch.serveCommands()
 
 	sch.logger.Info("successfully initialised")
 
@@ -77,99 +135,211 @@ func newDutySchedulerStopped(
 	stateDumper stateDumper,
 	dayOffsDB dayOffsDB,
 ) (*DutyScheduler, error) {
-	sch := &DutyScheduler{
+	sch := newDutyScheduler(cfg, stateDumper)
+
+	sch.logger.Info("initialising")
+
+	if err := sch.initNotifyChannels(); err != nil {
+		return nil, fmt.Errorf("could not init notification channel: %w", err)
+	}
+
+	sch.initOutbox()
+
+	if err := sch.initReminders(); err != nil {
+		return nil, err
+	}
+
+	if err := sch.initProject(cfg, dayOffsDB); err != nil {
+		return nil, err
+	}
+
+	return sch, nil
+}
+
+// NewStandaloneProject creates a project the same way DutyScheduler does: it restores
+// the state and connects the project to the day offs database and, if withVacations
+// is set, to the vacation database. However no changes are scheduled and no
+// notifications are sent. Can be useful to inspect the project without affecting it.
+func NewStandaloneProject(
+	cfg Config,
+	stateDumper stateDumper,
+	dayOffsDB dayOffsDB,
+	withVacations bool,
+) (*Project, error) {
+	return initProject(cfg, stateDumper, dayOffsDB, withVacations)
+}
+
+func newDutyScheduler(cfg Config, stateDumper stateDumper) *DutyScheduler {
+	return &DutyScheduler{
 		cfg: cfg,
 		logger: logrus.WithFields(map[string]interface{}{
 			"component": "duty_scheduler",
 			"project":   cfg.Name,
 		}),
-		stateDumper:    stateDumper,
-		eventsQ:        make(chan Event, 1),
-		shutdownOnce:   new(sync.Once),
-		shutdownInit:   make(chan struct{}),
-		eventsFinished: make(chan struct{}),
-		mu:             new(sync.RWMutex),
+		stateDumper:       stateDumper,
+		eventsQ:           make(chan Event, 1),
+		rescheduleQ:       make(chan struct{}, 1),
+		remindersQ:        make(chan struct{}, 1),
+		shutdownOnce:      new(sync.Once),
+		shutdownInit:      make(chan struct{}),
+		eventsFinished:    make(chan struct{}),
+		deliveryFinished:  new(sync.WaitGroup),
+		remindersFinished: new(sync.WaitGroup),
+		mu:                new(sync.RWMutex),
+		changeMu:          new(sync.Mutex),
 	}
+}
 
-	sch.logger.Info("initialising")
+// initNotifyChannels creates the configured channels. Channels are named by their
+// ids, the same destination configured several times is told apart by the number.
+func (sch *DutyScheduler) initNotifyChannels() error {
+	seen := make(map[string]int, len(sch.cfg.Channels))
 
-	if err := sch.initNotifyChannel(); err != nil {
-		return nil, fmt.Errorf("could not init notification channel: %w", err)
+	for _, config := range sch.cfg.Channels {
+		ch, err := newNotifyChannel(config)
+		if err != nil {
+			sch.shutdownNotifyChannels()
+
+			return fmt.Errorf("%s: %w", config.Type, err)
+		}
+
+		name := config.ID()
+		if seen[name]++; seen[name] > 1 {
+			name = fmt.Sprintf("%s#%d", name, seen[name])
+		}
+
+		sch.channels = append(sch.channels, &channel{
+			notifyChannel:    ch,
+			name:             name,
+			retryInterval:    config.RetryInterval,
+			maxRetryInterval: config.MaxRetryInterval,
+			maxAge:           config.MaxAge,
+			wakeUp:           make(chan struct{}, 1),
+		})
 	}
-	if err := sch.initProject(cfg); err != nil {
-		return nil, err
+
+	sch.logger.Infof("initialised %d notification channels", len(sch.channels))
+
+	return nil
+}
+
+// initOutbox restores notifications that were not delivered before the restart
+// if state persistence is enabled.
+func (sch *DutyScheduler) initOutbox() {
+	var store outbox.Store
+
+	if sch.cfg.StatePersistenceEnabled() {
+		switch d := sch.stateDumper.(type) {
+		case outboxDumper:
+			store = d.OutboxStore(sch.ProjectName())
+		case stateDirDumper:
+			store = outbox.NewFileStore(filepath.Join(d.Dir(), sch.ProjectName()+OutboxFileExt))
+		default:
+			store = outbox.NewFileStore(sch.ProjectName() + OutboxFileExt)
+		}
 	}
 
-	sch.project.SetDayOffsDB(dayOffsDB)
+	var err error
 
-	if cfg.Vacation.Enabled {
-		sch.logger.Info("initialising vacationdb")
+	sch.outbox, err = outbox.NewWithStore(store)
+	if err != nil {
+		sch.logger.Errorf("could not restore outbox, undelivered updates are lost: %v", err)
+	}
 
-		vacationDB, err := vacationdb.NewVacationDB(cfg.Vacation, sch.logger)
-		if err != nil {
-			return nil, err
+	known := make(map[string]bool, len(sch.channels))
+	for _, ch := range sch.channels {
+		known[ch.name] = true
+	}
+
+	for _, e := range sch.outbox.Entries() {
+		if known[e.Channel] {
+			continue
 		}
 
-		sch.project.SetVacationDB(vacationDB)
+		sch.logger.Warnf("dropping update for %s since the channel is not configured anymore", e.Channel)
 
-		sch.logger.Info("successfully initialised vacationdb")
+		if err := sch.outbox.Remove(e.ID); err != nil {
+			sch.logger.Errorf("could not remove update from outbox: %v", err)
+		}
 	}
 
-	return sch, nil
+	if n := sch.outbox.Len(); n != 0 {
+		sch.logger.Infof("restored %d undelivered updates", n)
+	}
 }
 
-func (sch *DutyScheduler) initNotifyChannel() (err error) {
-	switch notifychannel.Type(sch.cfg.Channel) {
+func newNotifyChannel(config ChannelConfig) (ch notifyChannel, err error) {
+	switch notifychannel.Type(config.Type) {
 	case notifychannel.EmptyChannelType:
-		sch.notifyChannel = notifychannel.EmptyNotifyChannel{}
+		ch = notifychannel.EmptyNotifyChannel{}
 	case notifychannel.StdOutChannelType:
-		sch.notifyChannel = notifychannel.StdOutNotifyChannel{}
+		ch = notifychannel.StdOutNotifyChannel{}
 	case notifychannel.MyTeamChannelType:
-		sch.notifyChannel, err = myteam.NewNotifyChannel(sch.cfg.MyTeam)
+		ch, err = myteam.NewNotifyChannel(config.MyTeam)
+	case notifychannel.SlackChannelType:
+		ch, err = slack.NewNotifyChannel(config.Slack)
+	case notifychannel.TelegramChannelType:
+		ch, err = telegram.NewNotifyChannel(config.Telegram)
+	case notifychannel.WebhookChannelType:
+		ch, err = webhook.NewNotifyChannel(config.Webhook)
+	case notifychannel.SMTPChannelType:
+		ch, err = smtp.NewNotifyChannel(config.SMTP)
+	default:
+		err = cfg.ErrNotSupported
 	}
 
+	return ch, err
+}
+
+func (sch *DutyScheduler) initProject(cfg Config, dayOffsDB dayOffsDB) error {
+	project, err := initProject(cfg, sch.stateDumper, dayOffsDB, true)
 	if err != nil {
 		return err
 	}
 
-	sch.logger.Info("initialised notification channel")
+	sch.project = project
 
 	return nil
 }
 
-func (sch *DutyScheduler) initProject(cfg Config) error {
-	newProject, err := NewProjectFromConfig(cfg)
+// initProject creates the project of the config, restores its state if persistence
+// is enabled and connects the project to the databases it consults.
+func initProject(
+	cfg Config,
+	stateDumper stateDumper,
+	dayOffsDB dayOffsDB,
+	withVacations bool,
+) (*Project, error) {
+	project, err := NewProjectFromConfig(cfg)
 	if err != nil {
-		return fmt.Errorf("invalid project: %w", err)
+		return nil, fmt.Errorf("invalid project: %w", err)
 	}
 
-	sch.project = newProject
-
-	if sch.cfg.StatePersistenceEnabled() {
-		sch.restoreState()
+	if cfg.StatePersistenceEnabled() {
+		project.restoreStateFrom(stateDumper)
 	}
 
-	sch.logger.Info("initialised project")
+	project.SetDayOffsDB(dayOffsDB)
 
-	return nil
-}
+	if withVacations && cfg.Vacation.Enabled {
+		project.logger.Info("initialising vacationdb")
 
-func (sch *DutyScheduler) restoreState() {
-	state, err := sch.stateDumper.GetState(sch.ProjectName())
-	if err != nil {
-		sch.logger.Errorf("could not get scheduling state from state dumper: %v", err)
-		return
-	}
+		vacationDB, err := vacationdb.NewVacationDB(cfg.Vacation, project.logger)
+		if err != nil {
+			return nil, err
+		}
 
-	if err := sch.project.RestoreState(state); err != nil {
-		sch.logger.Errorf("could not restore states: %v", err)
-		return
+		project.SetVacationDB(vacationDB)
+
+		project.logger.Info("successfully initialised vacationdb")
 	}
 
-	sch.logger.Infof(
-		"successfully restored state, current person of duty is %s, last change was %s",
-		sch.project.CurrentPerson(), sch.project.LastChange(),
-	)
+	// roles may have been added since the last change
+	project.AssignMissingRoles()
+
+	project.logger.Info("initialised project")
+
+	return project, nil
 }
 
 func (sch *DutyScheduler) eventsRoutine() {
@@ -177,19 +347,7 @@ func (sch *DutyScheduler) eventsRoutine() {
 
 LOOP:
 	for {
-		if sch.project.ShouldChangePerson() {
-			sch.project.SetTimeOfLastChange(time.Now())
-
-			sch.eventsQ <- Event{
-				newPerson: sch.project.NextPerson(),
-			}
-
-			if sch.project.StatePersistenceEnabled() {
-				if err := sch.stateDumper.Dump(sch.project); err != nil {
-					sch.logger.Errorf("could not dump state for project: %v", err)
-				}
-			}
-		} else {
+		if _, changed := sch.changePerson(false); !changed {
 			sch.logger.Info("timer triggered, but change of person is not needed")
 		}
 
@@ -202,7 +360,10 @@ LOOP:
 		select {
 		case <-timer.C:
 			// pass
+		case <-sch.rescheduleQ:
+			timer.Stop()
 		case <-sch.shutdownInit:
+			timer.Stop()
 			break LOOP
 		}
 	}
@@ -210,28 +371,375 @@ LOOP:
 	sch.logger.Info("finished scheduler loop")
 }
 
+// changePerson assigns the next person of duty if it is time to or if force
+// is set. It reports the new person and whether the change has happened.
+func (sch *DutyScheduler) changePerson(force bool) (string, bool) {
+	sch.changeMu.Lock()
+	defer sch.changeMu.Unlock()
+
+	return sch.changePersonLocked(force)
+}
+
+// changePersonLocked is changePerson for callers holding changeMu.
+func (sch *DutyScheduler) changePersonLocked(force bool) (string, bool) {
+	if !force && !sch.project.ShouldChangePerson() {
+		return "", false
+	}
+
+	prevPerson := sch.previousPerson()
+	now := time.Now()
+
+	sch.project.SetTimeOfLastChange(now)
+
+	next := sch.project.nextPerson(now)
+
+	sch.eventsQ <- sch.changeEvent(prevPerson, next.person, next.skippedForVacation)
+
+	sch.dumpState()
+	sch.recordHistory(
+		historyRecords(now, next, changeReason(next, force), sch.project.Region())...,
+	)
+	sch.wakeReminders()
+
+	return next.person, true
+}
+
+// changeEvent renders the message about the change of the person of duty.
+func (sch *DutyScheduler) changeEvent(
+	prevPerson, newPerson string, skippedForVacation []string,
+) Event {
+	data := MessageData{
+		Project:            sch.ProjectName(),
+		Current:            newPerson,
+		Previous:           prevPerson,
+		ShiftStart:         sch.project.LastChange(),
+		SkippedForVacation: skippedForVacation,
+		Roles:              sch.project.Roles(),
+		Region:             sch.project.Region(),
+	}
+
+	if schedule := sch.project.Schedule(1); len(schedule) != 0 {
+		data.Next = schedule[0].Person
+		data.ShiftEnd = schedule[0].Start
+	}
+
+	data.Backup = sch.project.backup(data.Roles, data.Next)
+
+	return Event{
+		newPerson:  newPerson,
+		prevPerson: prevPerson,
+		roles:      data.Roles,
+		nextChange: sch.nextChange(),
+		message:    sch.project.message,
+		data:       data,
+	}
+}
+
+// previousPerson returns the person of duty or an empty string if nobody
+// has been assigned yet.
+func (sch *DutyScheduler) previousPerson() string {
+	if sch.project.LastChange().IsZero() {
+		return ""
+	}
+
+	return sch.project.CurrentPerson()
+}
+
+// nextChange returns the time of the next scheduled change.
+func (sch *DutyScheduler) nextChange() time.Time {
+	return time.Now().Add(sch.project.TimeTillNextChange()).Truncate(time.Second)
+}
+
+// reschedule makes events and reminders routines recalculate the time of the next change.
+func (sch *DutyScheduler) reschedule() {
+	select {
+	case sch.rescheduleQ <- struct{}{}:
+	default:
+	}
+
+	sch.wakeReminders()
+}
+
+func (sch *DutyScheduler) dumpState() {
+	if !sch.project.StatePersistenceEnabled() {
+		return
+	}
+
+	if err := sch.stateDumper.Dump(sch.project); err != nil {
+		sch.logger.Errorf("could not dump state for project: %v", err)
+	}
+}
+
+// notificaionSenderRoutine puts every update to the outbox for each channel,
+// delivery routines of the channels take it from there.
 func (sch *DutyScheduler) notificaionSenderRoutine() {
 	for e := range sch.eventsQ {
-		sch.logger.Infof("new person on duty: %s", e.newPerson)
-
-		notificationText := fmt.Sprintf(sch.project.cfg.MessagePattern, e.newPerson)
+		if len(e.newPerson) != 0 {
+			sch.logger.Infof("new person on duty: %s", e.newPerson)
+		}
 
 		sch.mu.RLock()
-		notifyChannelCopy := sch.notifyChannel
+		channelsCopy := sch.channels
 		sch.mu.RUnlock()
 
-		if err := notifyChannelCopy.Send(notificationText); err != nil {
-			sch.logger.Infof("could not send update: %v", err)
+		for _, ch := range channelsCopy {
+			n := notifychannel.Notification{
+				Project:        sch.ProjectName(),
+				Text:           sch.eventText(e, ch.mention),
+				NewPerson:      e.newPerson,
+				PreviousPerson: e.prevPerson,
+				Roles:          e.roles,
+				NextChange:     e.nextChange,
+			}
+
+			if err := sch.outbox.Push(ch.name, n); err != nil {
+				sch.logger.Errorf(
+					"could not save update for %s, it is lost in case of restart: %v", ch.name, err,
+				)
+			}
+
+			ch.wake()
+		}
+	}
+}
+
+// eventText returns the message of the event as it is sent to a channel
+// mentioning persons with the given function.
+func (sch *DutyScheduler) eventText(e Event, mention mentionFunc) string {
+	if e.message == nil {
+		return e.text
+	}
+
+	return sch.project.renderTemplate(e.message, e.data, mention)
+}
+
+// startDeliveryRoutines launches a delivery routine for every channel.
+func (sch *DutyScheduler) startDeliveryRoutines() {
+	sch.mu.RLock()
+	defer sch.mu.RUnlock()
+
+	for _, ch := range sch.channels {
+		sch.deliveryFinished.Add(1)
+
+		go sch.deliveryRoutine(ch)
+	}
+}
+
+// deliveryRoutine sends updates from the outbox to the channel in order. An update
+// that failed is retried with exponential backoff, the next ones wait for it.
+// Channels do not wait for each other.
+func (sch *DutyScheduler) deliveryRoutine(ch *channel) {
+	defer sch.deliveryFinished.Done()
+
+	for {
+		var retryIn time.Duration
+
+		if e, ok := sch.outbox.Peek(ch.name); ok {
+			retryIn = sch.deliver(ch, e)
+		} else {
+			select {
+			case <-ch.wakeUp:
+				continue
+			case <-sch.shutdownInit:
+				return
+			}
+		}
+
+		if retryIn == 0 {
+			continue
+		}
+
+		timer := time.NewTimer(retryIn)
+
+		select {
+		case <-timer.C:
+		case <-sch.shutdownInit:
+			timer.Stop()
+			return
 		}
 	}
 }
 
-// SetNotifyChannel changes notify channel to the given.
-func (sch *DutyScheduler) SetNotifyChannel(ch notifyChannel) {
-	sch.mu.Lock()
-	defer sch.mu.Unlock()
+// deliver sends the update to the channel and removes it from the outbox unless
+// it has failed. In that case it returns the time to wait before the next attempt.
+func (sch *DutyScheduler) deliver(ch *channel, e outbox.Entry) time.Duration {
+	if age := time.Since(e.Created); age > ch.maxAge {
+		sch.logger.Errorf(
+			"could not send update to %s, giving up after %d attempts in %s",
+			ch.name, e.Attempts, age.Round(time.Second),
+		)
+
+		sch.removeFromOutbox(e)
+
+		return 0
+	}
+
+	err := ch.send(e.Notification)
+	if err == nil {
+		sch.removeFromOutbox(e)
+
+		return 0
+	}
+
+	attempts, markErr := sch.outbox.MarkFailed(e.ID)
+	if markErr != nil {
+		sch.logger.Errorf("could not save failed attempt for %s: %v", ch.name, markErr)
+		attempts = e.Attempts + 1
+	}
+
+	retryIn := ch.backoff(attempts)
+
+	sch.logger.Warnf(
+		"could not send update to %s (attempt %d), retrying in %s: %v", ch.name, attempts, retryIn, err,
+	)
+
+	return retryIn
+}
+
+func (sch *DutyScheduler) removeFromOutbox(e outbox.Entry) {
+	if err := sch.outbox.Remove(e.ID); err != nil {
+		sch.logger.Errorf("could not remove update from outbox, it may be sent again: %v", err)
+	}
+}
+
+// Swap exchanges the upcoming turns of the two given applicants, persists
+// the new state and notifies the chat.
+func (sch *DutyScheduler) Swap(person1, person2 string) error {
+	if err := sch.project.Swap(person1, person2); err != nil {
+		return err
+	}
+
+	sch.logger.Infof("%s and %s swapped their upcoming turns", person1, person2)
+
+	sch.dumpState()
+
+	sch.eventsQ <- Event{
+		text:       fmt.Sprintf("%s and %s swapped their upcoming turns", person1, person2),
+		nextChange: sch.nextChange(),
+	}
+
+	return nil
+}
+
+// HandOff passes the current duty to the given applicant till the next change,
+// persists the new state and notifies the chat.
+func (sch *DutyScheduler) HandOff(person string) error {
+	prevPerson, person, err := sch.project.HandOff(person)
+	if err != nil {
+		return err
+	}
+
+	sch.logger.Infof("%s handed off duty to %s", prevPerson, person)
+
+	sch.dumpState()
+	sch.recordHistory(statedumper.HistoryRecord{
+		Time:   time.Now(),
+		Person: person,
+		Reason: statedumper.ReasonSwap,
+		Region: sch.project.Region(),
+	})
+
+	sch.eventsQ <- sch.changeEvent(prevPerson, person, nil)
+
+	return nil
+}
+
+// Rotate changes the person of duty immediately regardless of the period
+// and returns the new person.
+func (sch *DutyScheduler) Rotate() string {
+	newPerson, _ := sch.changePerson(true)
+
+	sch.logger.Infof("forced change of person, %s is on duty now", newPerson)
+
+	sch.reschedule()
+
+	return newPerson
+}
+
+// Skip makes the given applicant skip their next turn. If the applicant
+// is on duty now, the duty is passed to the next person immediately.
+func (sch *DutyScheduler) Skip(person string) error {
+	if name, err := sch.project.resolveApplicant(person); err == nil {
+		person = name
+	}
+
+	// a scheduled change must not happen between the check and the rotation
+	sch.changeMu.Lock()
+
+	if !sch.project.LastChange().IsZero() && sch.project.CurrentPerson() == person {
+		newPerson, _ := sch.changePersonLocked(true)
+		sch.changeMu.Unlock()
+
+		sch.logger.Infof("%s is skipped while on duty, %s is on duty now", person, newPerson)
+		sch.reschedule()
+
+		return nil
+	}
+
+	err := sch.project.Skip(person)
+
+	sch.changeMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	sch.logger.Infof("%s will skip their next turn", person)
+
+	sch.dumpState()
+
+	return nil
+}
+
+// SetPaused pauses or resumes scheduled changes of the person of duty.
+func (sch *DutyScheduler) SetPaused(paused bool) {
+	sch.project.SetPaused(paused)
+
+	sch.logger.Infof("paused: %t", paused)
+
+	sch.dumpState()
+	sch.reschedule()
+}
+
+// Status describes the current state of a project.
+type Status struct {
+	Name               string     `json:"name"`
+	CurrentPerson      string     `json:"current_person"`
+	LastChange         time.Time  `json:"last_change"`
+	NextChange         *time.Time `json:"next_change"`           // nil while paused
+	TimeTillNextChange string     `json:"time_till_next_change"` // empty while paused
+	Paused             bool       `json:"paused"`
+
+	Roles  map[string]string `json:"roles,omitempty"`  // role -> person besides the primary one
+	Region string            `json:"region,omitempty"` // region on duty if the project follows the sun
+}
+
+// Status returns the current state of the project.
+func (sch *DutyScheduler) Status() Status {
+	status := Status{
+		Name:          sch.ProjectName(),
+		CurrentPerson: sch.currentPersonOrNobody(),
+		LastChange:    sch.project.LastChange(),
+		Paused:        sch.project.Paused(),
+		Roles:         sch.project.Roles(),
+		Region:        sch.project.Region(),
+	}
+
+	// no change is scheduled while the project is paused
+	if !status.Paused {
+		timeTillNextChange := sch.project.TimeTillNextChange()
+		nextChange := time.Now().Add(timeTillNextChange).Truncate(time.Second)
+
+		status.NextChange = &nextChange
+		status.TimeTillNextChange = timeTillNextChange.Round(time.Second).String()
+	}
+
+	return status
+}
 
-	sch.notifyChannel = ch
+// Stats returns the amount of duty every applicant of the project has done.
+func (sch *DutyScheduler) Stats() []ApplicantStats {
+	return sch.project.Stats()
 }
 
 // ProjectName returns a name of the project that this scheduler processes.
@@ -246,11 +754,74 @@ func (sch *DutyScheduler) Shutdown() {
 	// stop generating new events
 	sch.shutdownOnce.Do(func() { close(sch.shutdownInit) })
 	<-sch.eventsFinished
+	sch.remindersFinished.Wait()
+	sch.deliveryFinished.Wait()
 
-	if err := sch.notifyChannel.Shutdown(); err != nil {
-		sch.logger.Infof("could not shut down communicaion channel: %v", err)
-	}
+	sch.shutdownNotifyChannels()
 
-	sch.logger.Info("notification channel has been shut down")
+	sch.logger.Info("notification channels have been shut down")
 	sch.logger.Info("shutdown complete")
 }
+
+func (sch *DutyScheduler) shutdownNotifyChannels() {
+	sch.mu.RLock()
+	defer sch.mu.RUnlock()
+
+	for _, ch := range sch.channels {
+		if err := ch.Shutdown(); err != nil {
+			sch.logger.Infof("could not shut down communicaion channel %s: %v", ch.name, err)
+		}
+	}
+}
+
+// channel is a notification channel of the project along with its delivery settings
+type channel struct {
+	notifyChannel
+
+	name string
+
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+	maxAge           time.Duration
+
+	wakeUp chan struct{} // signals the delivery routine about new updates
+}
+
+func (ch *channel) wake() {
+	select {
+	case ch.wakeUp <- struct{}{}:
+	default:
+	}
+}
+
+// backoff returns the time to wait after the given number of failed attempts
+func (ch *channel) backoff(attempts int) time.Duration {
+	retryIn := ch.retryInterval
+
+	for i := 1; i < attempts && retryIn < ch.maxRetryInterval; i++ {
+		retryIn *= 2
+	}
+
+	if retryIn > ch.maxRetryInterval {
+		retryIn = ch.maxRetryInterval
+	}
+
+	return retryIn
+}
+
+// mention returns a mention of the person if the channel supports mentions
+func (ch *channel) mention(person notifychannel.Person) string {
+	if m, ok := ch.notifyChannel.(mentioner); ok {
+		return m.Mention(person)
+	}
+
+	return ""
+}
+
+func (ch *channel) send(n notifychannel.Notification) error {
+	if sender, ok := ch.notifyChannel.(notificationSender); ok {
+		return sender.SendNotification(n)
+	}
+
+	return ch.Send(n.Text)
+}
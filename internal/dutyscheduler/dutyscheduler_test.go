@@ -2,19 +2,42 @@ package dutyscheduler
 
 import (
 	"bufio"
+	"errors"
 	"log"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/gibsn/duty_bot/internal/notifychannel"
+	"github.com/gibsn/duty_bot/internal/outbox"
 	"github.com/gibsn/duty_bot/internal/statedumper"
+	"github.com/gibsn/duty_bot/internal/vacationdb"
 )
 
+// setNotifyChannel replaces all notify channels with the given one. Delivery routines
+// are started only for the channels existing at start, so it must be called on a
+// scheduler created by newDutySchedulerStopped before the routines are started.
+func (sch *DutyScheduler) setNotifyChannel(ch notifyChannel) {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+
+	sch.channels = []*channel{{
+		notifyChannel:    ch,
+		name:             "custom",
+		retryInterval:    defaultChannelRetryInterval,
+		maxRetryInterval: defaultChannelMaxRetryInterval,
+		maxAge:           defaultChannelMaxAge,
+		wakeUp:           make(chan struct{}, 1),
+	}}
+}
+
 func TestDutyScheduler(t *testing.T) {
 	config := Config{
 		Name:           "test_project",
-		Applicants:     "test1,test2",
+		Applicants:     ParseApplicants("test1,test2"),
 		MessagePattern: "%s",
 		Period:         string(EverySecond),
 	}
@@ -26,14 +49,15 @@ func TestDutyScheduler(t *testing.T) {
 		t.Fatalf("could not init dutyscheduler: %v", err)
 	}
 
-	sch.SetNotifyChannel(pipe)
+	sch.setNotifyChannel(pipe)
 	log.Println("info: tests: changed notifychannel to pipe, launching events routine")
 
 	go sch.eventsRoutine()
 	go sch.notificaionSenderRoutine()
+	sch.startDeliveryRoutines()
 
 	go func() {
-		validateIncomingEvents(t, config.Applicants, pipe)
+		validateIncomingEvents(t, config.Applicants.String(), pipe)
 		sch.Shutdown()
 	}()
 
@@ -54,7 +78,7 @@ func TestDutyScheduler(t *testing.T) {
 // 	pipe := notifychannel.NewPipe()
 //
 // 	sch, _ := NewDutyScheduler(config)
-// 	sch.SetNotifyChannel(pipe)
+// 	sch.setNotifyChannel(pipe)
 //
 // 	go func() {
 // 		validateIncomingEvents(t, *config.Mailx.DutyApplicants, pipe)
@@ -134,3 +158,320 @@ func (sch *DutyScheduler) watchdog(t *testing.T) {
 		sch.Shutdown()
 	}
 }
+
+// notificationRecorder is a notify channel accepting notifications with details.
+type notificationRecorder struct {
+	notifications chan notifychannel.Notification
+}
+
+func (r notificationRecorder) Send(text string) error {
+	return r.SendNotification(notifychannel.Notification{Text: text})
+}
+
+func (r notificationRecorder) SendNotification(n notifychannel.Notification) error {
+	r.notifications <- n
+
+	return nil
+}
+
+func (notificationRecorder) Shutdown() error {
+	return nil
+}
+
+func TestDutySchedulerSendNotification(t *testing.T) {
+	config := Config{
+		Name:           "test_project",
+		Applicants:     ParseApplicants("test1,test2"),
+		MessagePattern: "%s is on duty",
+		Period:         string(EveryDay),
+	}
+
+	sch, err := newDutySchedulerStopped(config, statedumper.NewDummyDumper(), nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	recorder := notificationRecorder{notifications: make(chan notifychannel.Notification, 16)}
+	sch.setNotifyChannel(recorder)
+
+	go sch.notificaionSenderRoutine()
+	sch.startDeliveryRoutines()
+
+	sch.Rotate()
+	sch.Rotate()
+
+	first, second := <-recorder.notifications, <-recorder.notifications
+
+	if first.Project != "test_project" || first.Text != "test1 is on duty" ||
+		first.NewPerson != "test1" || first.PreviousPerson != "" {
+		t.Errorf("unexpected first notification: %+v", first)
+	}
+
+	if second.NewPerson != "test2" || second.PreviousPerson != "test1" {
+		t.Errorf("unexpected second notification: %+v", second)
+	}
+
+	if till := time.Until(second.NextChange); till < 23*time.Hour || till > 24*time.Hour {
+		t.Errorf("next change must be in a day, got %s", second.NextChange)
+	}
+}
+
+// flakyChannel fails the given number of times before sending successfully.
+type flakyChannel struct {
+	failures int
+	attempts int
+	sent     []string
+}
+
+func (ch *flakyChannel) Send(text string) error {
+	ch.attempts++
+
+	if ch.attempts <= ch.failures {
+		return errors.New("temporary failure")
+	}
+
+	ch.sent = append(ch.sent, text)
+
+	return nil
+}
+
+func (ch *flakyChannel) Shutdown() error {
+	return nil
+}
+
+func TestDutySchedulerMultipleChannels(t *testing.T) {
+	config := Config{
+		Name:           "test_project",
+		Applicants:     ParseApplicants("test1,test2"),
+		MessagePattern: "%s is on duty",
+		Period:         string(EveryDay),
+	}
+
+	sch, err := newDutySchedulerStopped(config, statedumper.NewDummyDumper(), nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	reliable, flaky, broken := &flakyChannel{}, &flakyChannel{failures: 2}, &flakyChannel{failures: 1000}
+
+	newChannel := func(name string, ch notifyChannel, maxAge time.Duration) *channel {
+		return &channel{
+			notifyChannel:    ch,
+			name:             name,
+			retryInterval:    time.Millisecond,
+			maxRetryInterval: 4 * time.Millisecond,
+			maxAge:           maxAge,
+			wakeUp:           make(chan struct{}, 1),
+		}
+	}
+
+	sch.channels = []*channel{
+		newChannel("reliable", reliable, time.Hour),
+		newChannel("flaky", flaky, time.Hour),
+		newChannel("broken", broken, 50*time.Millisecond),
+	}
+
+	go sch.notificaionSenderRoutine()
+	sch.startDeliveryRoutines()
+
+	sch.Rotate()
+	sch.Rotate()
+
+	for deadline := time.Now().Add(5 * time.Second); sch.outbox.Len() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("updates were not delivered in time: %+v", sch.outbox.Entries())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sch.shutdownOnce.Do(func() { close(sch.shutdownInit) })
+	sch.deliveryFinished.Wait()
+
+	expected := []string{"test1 is on duty", "test2 is on duty"}
+
+	assert.Equal(t, expected, reliable.sent)
+	assert.Equal(t, 2, reliable.attempts)
+	assert.Equal(t, expected, flaky.sent, "order of updates must be kept")
+	assert.Equal(t, 4, flaky.attempts)
+	assert.Empty(t, broken.sent)
+}
+
+func TestDutySchedulerRestoreOutbox(t *testing.T) {
+	dir := t.TempDir()
+
+	fd, err := statedumper.NewFileDumper(dir)
+	if err != nil {
+		t.Fatalf("could not create file dumper: %v", err)
+	}
+
+	defer fd.Shutdown()
+
+	newHook := func(url string) ChannelConfig {
+		config := ChannelConfig{Type: "webhook"}
+		config.Webhook.URL = url
+
+		if err := config.Validate(); err != nil {
+			t.Fatalf("invalid channel config: %v", err)
+		}
+
+		return config
+	}
+
+	hook1, hook2, hook3 := newHook("http://127.0.0.1:1/hook1"),
+		newHook("http://127.0.0.1:1/hook2"), newHook("http://127.0.0.1:1/hook3")
+
+	o, err := outbox.New(filepath.Join(dir, "test_project"+OutboxFileExt))
+	if err != nil {
+		t.Fatalf("could not create outbox: %v", err)
+	}
+
+	assert.NoError(t, o.Push(hook1.ID(), notifychannel.Notification{Text: "for hook1"}))
+	assert.NoError(t, o.Push(hook2.ID(), notifychannel.Notification{Text: "for hook2"}))
+
+	// hook1 has been removed, hook2 has moved to the second place
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2"),
+		Period:     string(EveryDay),
+		Persist:    true,
+		Channels:   []ChannelConfig{hook3, hook2},
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	sch, err := newDutySchedulerStopped(config, fd, nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	if entries := sch.outbox.Entries(); assert.Len(t, entries, 1) {
+		assert.Equal(t, sch.channels[1].name, entries[0].Channel)
+		assert.Equal(t, "for hook2", entries[0].Notification.Text)
+	}
+}
+
+// stateDumperMock keeps the states of the projects by their names
+type stateDumperMock map[string]statedumper.SchedulingState
+
+func (d stateDumperMock) Dump(statedumper.Dumpable) error {
+	return nil
+}
+
+func (d stateDumperMock) GetState(name string) (statedumper.SchedulingState, error) {
+	return d[name], nil
+}
+
+func TestNewStandaloneProject(t *testing.T) {
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Period:     string(EveryDay),
+		Persist:    true,
+	}
+
+	saved, err := NewProjectFromConfig(config)
+	if err != nil {
+		t.Fatalf("could not init project: %v", err)
+	}
+
+	saved.NextPerson()
+	saved.NextPerson()
+
+	state, err := statedumper.NewSchedulingState(strings.NewReader(dumpTestState(t, saved)))
+	if err != nil {
+		t.Fatalf("could not parse state: %v", err)
+	}
+
+	// the vacation database is not connected unless asked to
+	config.Vacation.Enabled = true
+	config.Vacation.Type = vacationdb.CalDAVType
+
+	project, err := NewStandaloneProject(
+		config, stateDumperMock{"test_project": state}, holidaysDBMock{}, false,
+	)
+	if err != nil {
+		t.Fatalf("could not init standalone project: %v", err)
+	}
+
+	assert.Equal(t, "test2", project.CurrentPerson())
+	assert.Nil(t, project.vacationDB)
+	assert.NotNil(t, project.dayOffsDB)
+}
+
+func TestDutySchedulerStatusPaused(t *testing.T) {
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2"),
+		Period:     string(EveryDay),
+	}
+
+	sch, err := newDutySchedulerStopped(config, statedumper.NewDummyDumper(), nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	status := sch.Status()
+	if assert.NotNil(t, status.NextChange) {
+		assert.True(t, status.NextChange.After(time.Now()))
+	}
+
+	assert.NotEmpty(t, status.TimeTillNextChange)
+
+	// nothing is going to change while paused
+	sch.SetPaused(true)
+
+	status = sch.Status()
+	assert.True(t, status.Paused)
+	assert.Nil(t, status.NextChange)
+	assert.Empty(t, status.TimeTillNextChange)
+}
+
+func TestDutySchedulerSkipDuringChange(t *testing.T) {
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Period:     string(EveryDay),
+	}
+
+	sch, err := newDutySchedulerStopped(config, statedumper.NewDummyDumper(), nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	go sch.notificaionSenderRoutine()
+
+	sch.Rotate()
+
+	// test1 is asked to be skipped while a scheduled change is going on
+	sch.changeMu.Lock()
+
+	skipped := make(chan error)
+	go func() { skipped <- sch.Skip("test1") }()
+
+	time.Sleep(10 * time.Millisecond)
+	sch.changePersonLocked(true)
+	sch.changeMu.Unlock()
+
+	assert.NoError(t, <-skipped)
+
+	// test2 keeps the duty, test1 skips their next turn instead
+	assert.Equal(t, "test2", sch.project.CurrentPerson())
+	assert.Equal(t, "test3", sch.project.NextPerson())
+	assert.Equal(t, "test2", sch.project.NextPerson())
+}
+
+func TestChannelBackoff(t *testing.T) {
+	ch := channel{retryInterval: time.Second, maxRetryInterval: 10 * time.Second}
+
+	var backoffs []time.Duration
+	for attempts := 1; attempts <= 6; attempts++ {
+		backoffs = append(backoffs, ch.backoff(attempts))
+	}
+
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+	}, backoffs)
+}
@@ -0,0 +1,187 @@
+package dutyscheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+const (
+	// totals are compared with this precision so that a few seconds of delay
+	// in a change do not break the order of the rotation
+	dutyPrecision = time.Hour
+
+	day = 24 * time.Hour
+)
+
+// ApplicantStats is the amount of duty an applicant has done
+type ApplicantStats struct {
+	Name    string  `json:"name"`
+	Days    float64 `json:"days"`     // time on duty in days
+	DayOffs float64 `json:"day_offs"` // time on duty at day offs in days
+}
+
+func (s ApplicantStats) String() string {
+	return fmt.Sprintf("%s: %.1f days, %.1f day offs", s.Name, s.Days, s.DayOffs)
+}
+
+// dutyTotals returns the time between from and till along with the part of it
+// at day offs, dates are checked in the given time zone
+func (p *Project) dutyTotals(from, till time.Time, loc *time.Location) statedumper.DutyTotals {
+	var totals statedumper.DutyTotals
+
+	for from = from.In(loc); from.Before(till); {
+		year, month, date := from.Date()
+
+		end := time.Date(year, month, date+1, 0, 0, 0, 0, loc)
+		if end.After(till) {
+			end = till
+		}
+
+		totals.Duty += end.Sub(from)
+
+		// lack of info is not reported here since it is expected for the past
+		if isDayOff, _ := p.checkDayOff(from); isDayOff {
+			totals.DayOffs += end.Sub(from)
+		}
+
+		from = end
+	}
+
+	return totals
+}
+
+// addDuty adds the time between from and till to the totals of the person
+func (p *Project) addDuty(
+	totals map[string]statedumper.DutyTotals, person string, from, till time.Time,
+) {
+	shift := p.dutyTotals(from, till, p.applicants[person].location())
+
+	total := totals[person]
+	total.Duty += shift.Duty
+	total.DayOffs += shift.DayOffs
+
+	totals[person] = total
+}
+
+// countDuty adds the time since the duty was last counted to the totals of the
+// person on duty
+func (p *Project) countDuty(till time.Time) {
+	if !p.countedTill.IsZero() && p.countedTill.Before(till) {
+		person := p.currentPersonLocked()
+
+		p.addDuty(p.totals, person, p.countedTill, till)
+		p.lastDuty[person] = till
+	}
+
+	p.countedTill = till
+}
+
+// turnOrder returns the positions in the rotation in the order the applicants are
+// offered the shift starting at t: round robin after the given position or, in
+// fairness mode, the ones with less duty first. Shifts covering day offs go to
+// the ones with less duty at day offs first.
+func (p *Project) turnOrder(
+	applicants []string, position uint64, totals map[string]statedumper.DutyTotals, t time.Time,
+) []uint64 {
+	order := make([]uint64, 0, len(applicants))
+	for i := 1; i <= len(applicants); i++ {
+		order = append(order, position+uint64(i))
+	}
+
+	if !p.cfg.Fairness {
+		return order
+	}
+
+	n := uint64(len(applicants))
+	dayOffsShift := p.coversDayOffs(t)
+
+	// weights are considered by comparing the duty per unit of weight
+	sort.SliceStable(order, func(i, j int) bool {
+		person1, person2 := applicants[order[i]%n], applicants[order[j]%n]
+
+		return lessDuty(
+			p.weighted(person1, totals[person1]), p.weighted(person2, totals[person2]), dayOffsShift,
+		)
+	})
+
+	return order
+}
+
+func lessDuty(t1, t2 statedumper.DutyTotals, dayOffsFirst bool) bool {
+	duty1, duty2 := t1.Duty.Round(dutyPrecision), t2.Duty.Round(dutyPrecision)
+	dayOffs1, dayOffs2 := t1.DayOffs.Round(dutyPrecision), t2.DayOffs.Round(dutyPrecision)
+
+	if dayOffsFirst && dayOffs1 != dayOffs2 {
+		return dayOffs1 < dayOffs2
+	}
+
+	return duty1 < duty2
+}
+
+// coversDayOffs reports whether the shift starting at t includes day offs
+func (p *Project) coversDayOffs(t time.Time) bool {
+	return p.dutyTotals(t, p.nextPeriod(t), time.Local).DayOffs > 0
+}
+
+func (p *Project) copyTotals() map[string]statedumper.DutyTotals {
+	totals := make(map[string]statedumper.DutyTotals, len(p.totals))
+	for person, total := range p.totals {
+		totals[person] = total
+	}
+
+	return totals
+}
+
+// Stats returns the amount of duty every applicant has done including the current
+// shift. Active applicants go first in the order of the rotation, the ones that
+// have left follow in alphabetical order.
+func (p *Project) Stats() []ApplicantStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	totals := p.copyTotals()
+
+	if !p.countedTill.IsZero() {
+		p.addDuty(totals, p.currentPersonLocked(), p.countedTill, time.Now())
+	}
+
+	names := append([]string(nil), p.dutyApplicants...)
+
+	var others []string
+
+	for name := range totals {
+		if indexOf(p.dutyApplicants, name) < 0 {
+			others = append(others, name)
+		}
+	}
+
+	sort.Strings(others)
+
+	stats := make([]ApplicantStats, 0, len(names)+len(others))
+
+	for _, name := range append(names, others...) {
+		stats = append(stats, ApplicantStats{
+			Name:    name,
+			Days:    float64(totals[name].Duty) / float64(day),
+			DayOffs: float64(totals[name].DayOffs) / float64(day),
+		})
+	}
+
+	return stats
+}
+
+// FormatStats returns a human readable report of the duty every applicant has done
+func (p *Project) FormatStats() string {
+	stats := p.Stats()
+
+	lines := make([]string, 0, len(stats))
+	for _, s := range stats {
+		lines = append(lines, s.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,116 @@
+package dutyscheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+func schedulePersons(schedule []ScheduleEntry) []string {
+	persons := make([]string, 0, len(schedule))
+	for _, entry := range schedule {
+		persons = append(persons, entry.Person)
+	}
+
+	return persons
+}
+
+func TestProjectFairnessCatchUp(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Period:     string(EveryDay),
+		Fairness:   true,
+	})
+
+	// test3 has just returned from vacation
+	project.totals = map[string]statedumper.DutyTotals{
+		"test1": {Duty: 3 * day},
+		"test2": {Duty: 3 * day},
+		"test3": {Duty: day},
+	}
+
+	monday := time.Date(2024, time.January, 8, 10, 0, 0, 0, time.Local)
+
+	assert.Equal(t,
+		[]string{"test3", "test3", "test1", "test2", "test3"},
+		schedulePersons(project.schedule(monday, 5)),
+	)
+
+	// the same rotation is strict round robin without fairness
+	project.cfg.Fairness = false
+
+	assert.Equal(t,
+		[]string{"test1", "test2", "test3", "test1", "test2"},
+		schedulePersons(project.schedule(monday, 5)),
+	)
+}
+
+func TestProjectFairnessDayOffs(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Period:     string(EveryDay),
+		Fairness:   true,
+	})
+
+	project.totals = map[string]statedumper.DutyTotals{
+		"test1": {Duty: 5 * day},
+		"test2": {Duty: 3 * day, DayOffs: 2 * day},
+		"test3": {Duty: 4 * day},
+	}
+
+	friday := time.Date(2024, time.January, 12, 10, 0, 0, 0, time.Local)
+
+	// shifts covering the weekend go to the ones with less duty at day offs, the one
+	// with less duty in total goes first among equals; test3 has got the least of
+	// the weekend by Sunday
+	assert.Equal(t,
+		[]string{"test3", "test1", "test3"},
+		schedulePersons(project.schedule(friday, 3)),
+	)
+}
+
+func TestProjectStats(t *testing.T) {
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Period:     string(EveryDay),
+		Fairness:   true,
+	}
+
+	project := newTestProject(t, config)
+
+	assert.Equal(t, "test1", project.NextPerson())
+
+	project.countedTill = project.countedTill.Add(-36 * time.Hour)
+	project.totals["left"] = statedumper.DutyTotals{Duty: day}
+
+	stats := project.Stats()
+
+	assert.Equal(t, []string{"test1", "test2", "test3", "left"}, []string{
+		stats[0].Name, stats[1].Name, stats[2].Name, stats[3].Name,
+	})
+	assert.InDelta(t, 1.5, stats[0].Days, 0.01)
+	assert.Equal(t, 0.0, stats[1].Days)
+	assert.Equal(t, 1.0, stats[3].Days)
+
+	assert.Equal(t, "test2", project.NextPerson())
+	assert.InDelta(t, 1.5, project.totals["test1"].Duty.Hours()/24, 0.01)
+
+	buf := &strings.Builder{}
+	assert.NoError(t, project.DumpState(buf))
+
+	state, err := statedumper.NewSchedulingState(strings.NewReader(buf.String()))
+	assert.NoError(t, err)
+
+	restored := newTestProject(t, config)
+	assert.NoError(t, restored.RestoreState(state))
+
+	assert.Equal(t, project.totals["test1"].Duty.Truncate(time.Second), restored.totals["test1"].Duty)
+	assert.Equal(t, project.countedTill.Unix(), restored.countedTill.Unix())
+}
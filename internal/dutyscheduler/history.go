@@ -0,0 +1,139 @@
+package dutyscheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+// DateLayout is the layout of dates of the history queries
+const DateLayout = "2006-01-02"
+
+var (
+	ErrHistoryNotKept = errors.New("history is not kept")
+)
+
+// historyDumper keeps the append-only duty history of the projects
+type historyDumper interface {
+	AppendHistory(project string, records ...statedumper.HistoryRecord) error
+	History(project string, from, till time.Time) ([]statedumper.HistoryRecord, error)
+}
+
+// ParseDateRange parses the dates of a history query in the local time zone. Both
+// dates are inclusive, the returned range is [from, till). An empty date leaves the
+// range open from that side.
+func ParseDateRange(from, till string) (time.Time, time.Time, error) {
+	var fromTime, tillTime time.Time
+
+	if len(from) != 0 {
+		t, err := time.ParseInLocation(DateLayout, from, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from '%s': %w", from, cfg.ErrInvalidValue)
+		}
+
+		fromTime = t
+	}
+
+	if len(till) != 0 {
+		t, err := time.ParseInLocation(DateLayout, till, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("till '%s': %w", till, cfg.ErrInvalidValue)
+		}
+
+		tillTime = t.AddDate(0, 0, 1)
+	}
+
+	if !fromTime.IsZero() && !tillTime.IsZero() && !fromTime.Before(tillTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from is after till: %w", cfg.ErrInvalidValue)
+	}
+
+	return fromTime, tillTime, nil
+}
+
+// historyRecords makes the records of the change of the person of duty at the
+// given time: the applicants whose turn was passed due to vacation go first
+func historyRecords(
+	now time.Time, next turn, reason statedumper.HistoryReason, region string,
+) []statedumper.HistoryRecord {
+	records := make([]statedumper.HistoryRecord, 0, len(next.skippedForVacation)+1)
+
+	for _, person := range next.skippedForVacation {
+		records = append(records, statedumper.HistoryRecord{
+			Time:   now,
+			Person: person,
+			Reason: statedumper.ReasonSkippedForVacation,
+			Region: region,
+		})
+	}
+
+	if len(next.person) != 0 {
+		records = append(records, statedumper.HistoryRecord{
+			Time:   now,
+			Person: next.person,
+			Reason: reason,
+			Region: region,
+		})
+	}
+
+	return records
+}
+
+// changeReason returns the reason the person has taken duty
+func changeReason(next turn, force bool) statedumper.HistoryReason {
+	switch {
+	case force:
+		return statedumper.ReasonForced
+	case next.substitute:
+		return statedumper.ReasonSwap
+	}
+
+	return statedumper.ReasonScheduled
+}
+
+// historyDumper returns the storage of the history, nil if history is not kept
+func (sch *DutyScheduler) historyDumper() historyDumper {
+	if !sch.project.StatePersistenceEnabled() {
+		return nil
+	}
+
+	hd, ok := sch.stateDumper.(historyDumper)
+	if !ok {
+		return nil
+	}
+
+	return hd
+}
+
+func (sch *DutyScheduler) recordHistory(records ...statedumper.HistoryRecord) {
+	hd := sch.historyDumper()
+	if hd == nil {
+		return
+	}
+
+	if err := hd.AppendHistory(sch.ProjectName(), records...); err != nil {
+		sch.logger.Errorf("could not record history: %v", err)
+	}
+}
+
+// History returns the records of the duty history of the project made in
+// [from, till) in chronological order, zero bounds are not checked.
+func (sch *DutyScheduler) History(from, till time.Time) ([]statedumper.HistoryRecord, error) {
+	hd := sch.historyDumper()
+	if hd == nil {
+		return nil, fmt.Errorf("'%s': %w", sch.ProjectName(), ErrHistoryNotKept)
+	}
+
+	records, err := hd.History(sch.ProjectName(), from, till)
+	if err != nil {
+		return nil, err
+	}
+
+	if records == nil {
+		records = []statedumper.HistoryRecord{}
+	}
+
+	return records, nil
+}
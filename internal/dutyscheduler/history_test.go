@@ -0,0 +1,146 @@
+package dutyscheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+func historyPersons(records []statedumper.HistoryRecord) []string {
+	persons := make([]string, 0, len(records))
+
+	for _, r := range records {
+		persons = append(persons, r.Person+":"+string(r.Reason))
+	}
+
+	return persons
+}
+
+func TestDutySchedulerHistory(t *testing.T) {
+	fd, err := statedumper.NewFileDumper(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create file dumper: %v", err)
+	}
+
+	defer fd.Shutdown()
+
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Period:     string(EveryDay),
+		Persist:    true,
+	}
+
+	sch, err := newDutySchedulerStopped(config, fd, nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	go sch.notificaionSenderRoutine()
+
+	start := time.Now()
+
+	sch.Rotate()
+	assert.NoError(t, sch.HandOff("test3"))
+	sch.Rotate()
+
+	records, err := sch.History(time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]string{"test1:forced", "test3:swap", "test2:forced"}, historyPersons(records),
+	)
+
+	// the range is half-open
+	records, err = sch.History(start.Add(-time.Hour), start)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	// nothing is recorded for projects without persistence
+	config.Persist = false
+
+	sch, err = newDutySchedulerStopped(config, fd, nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	_, err = sch.History(time.Time{}, time.Time{})
+	assert.ErrorIs(t, err, ErrHistoryNotKept)
+}
+
+func TestDutySchedulerHandOffByAlias(t *testing.T) {
+	fd, err := statedumper.NewFileDumper(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create file dumper: %v", err)
+	}
+
+	defer fd.Shutdown()
+
+	config := Config{
+		Name: "test_project",
+		Applicants: Applicants{
+			{Name: "test1"}, {Name: "test2"}, {Name: "test3", Aliases: []string{"alias3"}},
+		},
+		Period:  string(EveryDay),
+		Persist: true,
+	}
+
+	sch, err := newDutySchedulerStopped(config, fd, nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	sch.Rotate()
+	<-sch.eventsQ
+
+	// the applicant is known by the name everywhere
+	assert.NoError(t, sch.HandOff("alias3"))
+
+	e := <-sch.eventsQ
+	assert.Equal(t, "test3", e.newPerson)
+	assert.Equal(t, "test1", e.prevPerson)
+
+	records, err := sch.History(time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test1:forced", "test3:swap"}, historyPersons(records))
+}
+
+func TestHistoryRecords(t *testing.T) {
+	now := time.Date(2024, time.January, 8, 10, 0, 0, 0, time.Local)
+
+	next := turn{person: "test3", substitute: true, skippedForVacation: []string{"test1"}}
+
+	records := historyRecords(now, next, changeReason(next, false), "eu")
+
+	assert.Equal(t, []statedumper.HistoryRecord{
+		{Time: now, Person: "test1", Reason: statedumper.ReasonSkippedForVacation, Region: "eu"},
+		{Time: now, Person: "test3", Reason: statedumper.ReasonSwap, Region: "eu"},
+	}, records)
+
+	assert.Equal(t, statedumper.ReasonForced, changeReason(next, true))
+	assert.Equal(t, statedumper.ReasonScheduled, changeReason(turn{person: "test1"}, false))
+
+	// nobody is available
+	records = historyRecords(now, turn{skippedForVacation: []string{"test1"}}, "", "")
+	assert.Len(t, records, 1)
+}
+
+func TestParseDateRange(t *testing.T) {
+	from, till, err := ParseDateRange("2024-01-01", "2024-01-31")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.Local), from)
+	assert.Equal(t, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.Local), till)
+
+	from, till, err = ParseDateRange("", "")
+	assert.NoError(t, err)
+	assert.True(t, from.IsZero())
+	assert.True(t, till.IsZero())
+
+	for _, r := range [][2]string{{"2024-01-32", ""}, {"", "01.01.2024"}, {"2024-02-01", "2024-01-31"}} {
+		_, _, err = ParseDateRange(r[0], r[1])
+		assert.ErrorIs(t, err, cfg.ErrInvalidValue, "%v", r)
+	}
+}
@@ -0,0 +1,141 @@
+package dutyscheduler
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+const (
+	defaultMessagePattern = "{{mention .Current}} is on duty"
+
+	legacyMessageVerb = "%s"
+	templateDelim     = "{{"
+)
+
+// MessageData is passed to the message template on every change of the person
+// of duty, e.g.
+//
+//	{{mention .Current}} is on duty till {{.ShiftEnd.Format "Mon 15:04"}}, {{name .Next}} is next
+//
+// Persons are given by their names in the list of applicants, use name function
+// to display them and mention function to mention them in messengers.
+type MessageData struct {
+	Project string
+
+	Current  string // the new person of duty
+	Previous string // empty if nobody has been on duty
+	Next     string // who takes the duty after Current, empty if no changes are scheduled
+	Backup   string // who covers Current: holder of the first role or the next person if no roles
+	Region   string // region covering the shift of Current, empty unless the project follows the sun
+
+	Roles map[string]string // role -> person, the primary role held by Current is not included
+
+	ShiftStart time.Time
+	ShiftEnd   time.Time // zero if no changes are scheduled
+
+	SkippedForVacation []string // applicants whose turn was passed since they are on vacation
+}
+
+// mentionFunc returns a mention of the person in a messenger, empty if the
+// messenger has no means to mention the person
+type mentionFunc func(notifychannel.Person) string
+
+var messageTemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+	// replaced with the ones aware of profiles on rendering
+	"name":    func(person string) string { return person },
+	"mention": func(person string) string { return person },
+}
+
+// newMessageTemplate parses the message pattern. Patterns with a single %s
+// that were used with fmt.Sprintf are still accepted, %s stands for the new
+// person of duty.
+func newMessageTemplate(pattern string) (*template.Template, error) {
+	if len(pattern) == 0 {
+		pattern = defaultMessagePattern
+	}
+
+	if !strings.Contains(pattern, templateDelim) {
+		switch strings.Count(pattern, legacyMessageVerb) {
+		case 0:
+		case 1:
+			pattern = strings.Replace(pattern, legacyMessageVerb, "{{mention .Current}}", 1)
+		default:
+			return nil, fmt.Errorf(
+				"only one %s is allowed in legacy patterns: %w", legacyMessageVerb, cfg.ErrInvalidValue,
+			)
+		}
+	}
+
+	tmpl, err := template.New("message").Funcs(messageTemplateFuncs).Parse(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, cfg.ErrInvalidValue)
+	}
+
+	// catches references to unknown fields that Parse does not check
+	if err := tmpl.Execute(&strings.Builder{}, sampleMessageData()); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, cfg.ErrInvalidValue)
+	}
+
+	return tmpl, nil
+}
+
+func sampleMessageData() MessageData {
+	now := time.Now()
+
+	return MessageData{
+		Project:            "project",
+		Current:            "current",
+		Previous:           "previous",
+		Next:               "next",
+		Backup:             "backup",
+		Roles:              map[string]string{"backup": "backup"},
+		ShiftStart:         now,
+		ShiftEnd:           now.Add(24 * time.Hour), // nolint: gomnd
+		SkippedForVacation: []string{"skipped"},
+	}
+}
+
+// renderTemplate executes the given message template, persons are mentioned
+// with the given function if it is not nil. In case the template fails the
+// message falls back to the default one.
+func (p *Project) renderTemplate(
+	tmpl *template.Template, data MessageData, mention mentionFunc,
+) string {
+	name := func(person string) string {
+		return p.Person(person).String()
+	}
+
+	tmpl, err := tmpl.Clone()
+	if err == nil {
+		tmpl.Funcs(template.FuncMap{
+			"name": name,
+			"mention": func(person string) string {
+				if len(person) == 0 || mention == nil {
+					return name(person)
+				}
+
+				if m := mention(p.Person(person)); len(m) != 0 {
+					return m
+				}
+
+				return name(person)
+			},
+		})
+
+		buf := &strings.Builder{}
+
+		if err = tmpl.Execute(buf, data); err == nil {
+			return buf.String()
+		}
+	}
+
+	p.logger.Errorf("could not render message, falling back to the default one: %v", err)
+
+	return fmt.Sprintf("%s is on duty", name(data.Current))
+}
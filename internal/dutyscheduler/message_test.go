@@ -0,0 +1,146 @@
+package dutyscheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+func TestNewMessageTemplate(t *testing.T) {
+	data := MessageData{
+		Project:            "test_project",
+		Current:            "test2",
+		Previous:           "test1",
+		Next:               "test3",
+		Backup:             "test3",
+		ShiftStart:         time.Date(2022, time.March, 1, 10, 0, 0, 0, time.UTC),
+		ShiftEnd:           time.Date(2022, time.March, 2, 10, 0, 0, 0, time.UTC),
+		SkippedForVacation: []string{"test4", "test5"},
+	}
+
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{"", "test2 is on duty"},
+		{"%s is on duty", "test2 is on duty"},
+		{"rotation happened", "rotation happened"},
+		{
+			"[{{.Project}}] {{.Previous}} -> {{.Current}} till {{.ShiftEnd.Format \"Jan 2 15:04\"}}, " +
+				"then {{.Next}}{{if .SkippedForVacation}} ({{join .SkippedForVacation \", \"}} on vacation){{end}}",
+			"[test_project] test1 -> test2 till Mar 2 10:00, then test3 (test4, test5 on vacation)",
+		},
+		{"100% {{.Current}}, %s stays as is", "100% test2, %s stays as is"},
+	}
+
+	for _, testCase := range testCases {
+		tmpl, err := newMessageTemplate(testCase.pattern)
+		if err != nil {
+			t.Errorf("pattern '%s': unexpected error: %v", testCase.pattern, err)
+			continue
+		}
+
+		buf := &strings.Builder{}
+		assert.NoError(t, tmpl.Execute(buf, data))
+		assert.Equal(t, testCase.expected, buf.String())
+	}
+
+	for _, pattern := range []string{"%s and %s", "{{.Current", "{{.Unknown}}", "{{.Current | nofunc}}"} {
+		_, err := newMessageTemplate(pattern)
+		assert.ErrorIs(t, err, cfg.ErrInvalidValue, "pattern '%s'", pattern)
+	}
+}
+
+func TestDutySchedulerMessage(t *testing.T) {
+	config := Config{
+		Name:           "test_project",
+		Applicants:     ParseApplicants("test1,test2,test3"),
+		MessagePattern: "{{.Previous}} -> {{.Current}}, next is {{.Next}} in {{.ShiftEnd.Sub .ShiftStart}}",
+		Period:         string(EveryDay),
+	}
+
+	sch, err := newDutySchedulerStopped(config, statedumper.NewDummyDumper(), nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	sch.Rotate()
+	assert.Equal(t, " -> test1, next is test2 in 24h0m0s", sch.eventText(<-sch.eventsQ, nil))
+
+	sch.Rotate()
+	assert.Equal(t, "test1 -> test2, next is test3 in 24h0m0s", sch.eventText(<-sch.eventsQ, nil))
+}
+
+func TestDutySchedulerMentions(t *testing.T) {
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Profiles: map[string]ProfileConfig{
+			"test1": {DisplayName: "Test One", ContactsConfig: ContactsConfig{Slack: "U1"}},
+			"test2": {DisplayName: "Test Two", ContactsConfig: ContactsConfig{Slack: "U2"}},
+			"test3": {DisplayName: "Test Three"},
+		},
+		MessagePattern: "{{mention .Current}} is on duty, {{name .Next}} is next" +
+			"{{if .SkippedForVacation}}, {{join .SkippedForVacation \",\"}} on vacation{{end}}",
+		Period: string(EveryDay),
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	sch, err := newDutySchedulerStopped(config, statedumper.NewDummyDumper(), nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	// vacations are still looked up by the names of applicants
+	sch.project.SetVacationDB(dummyVacationDB{true, "test2"})
+
+	slackMention := func(person notifychannel.Person) string {
+		if len(person.Slack) == 0 {
+			return ""
+		}
+
+		return "<@" + person.Slack + ">"
+	}
+
+	sch.Rotate()
+
+	e := <-sch.eventsQ
+	assert.Equal(t, "<@U1> is on duty, Test Three is next", sch.eventText(e, slackMention))
+	assert.Equal(t, "Test One is on duty, Test Three is next", sch.eventText(e, nil))
+
+	sch.Rotate()
+
+	e = <-sch.eventsQ
+	assert.Equal(t, "Test Three is on duty, Test One is next, test2 on vacation", sch.eventText(e, slackMention))
+}
+
+func TestConfigValidateProfiles(t *testing.T) {
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2"),
+		Profiles: map[string]ProfileConfig{
+			"test1": {ContactsConfig: ContactsConfig{
+				Telegram: "@test1", Email: "Test One <test1@example.com>",
+			}},
+		},
+	}
+
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, "test1", config.Profiles["test1"].Telegram)
+
+	config.Profiles["test3"] = ProfileConfig{}
+	assert.ErrorIs(t, config.Validate(), cfg.ErrInvalidValue)
+
+	delete(config.Profiles, "test3")
+	config.Profiles["test2"] = ProfileConfig{ContactsConfig: ContactsConfig{Email: "not an email"}}
+	assert.ErrorIs(t, config.Validate(), cfg.ErrInvalidValue)
+}
@@ -1,11 +1,22 @@
 package dutyscheduler
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gibsn/duty_bot/internal/cfg"
 )
 
+// PeriodType describes how often the person of duty is changed. Besides the fixed
+// periods counted from the last change, it can be aligned to the wall clock:
+//
+//	every day at 10:00 [Europe/Moscow]
+//	every monday at 10:00 [Europe/Moscow]
+//	cron 0 10 * * 1 [Europe/Moscow]
+//
+// Time zone is optional, local time zone is used by default.
 type PeriodType string
 
 const (
@@ -18,7 +29,39 @@ const (
 	Every4Weeks PeriodType = "every 4 weeks"
 )
 
+const (
+	everyPrefix = "every "
+	atSeparator = " at "
+	cronPrefix  = "cron "
+
+	cronFieldsNum = 5
+	timeOfDayLen  = 2
+
+	// how far Trigger.Next looks for the next time to fire
+	maxTriggerSearchDays = 5 * 366
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
 func (t PeriodType) Validate() error {
+	if t.isFixed() {
+		return nil
+	}
+
+	_, err := t.Trigger()
+
+	return err
+}
+
+func (t PeriodType) isFixed() bool {
 	switch t {
 	case EverySecond:
 		fallthrough
@@ -33,12 +76,14 @@ func (t PeriodType) Validate() error {
 	case Every2Weeks:
 		fallthrough
 	case Every4Weeks:
-		return nil
+		return true
 	}
 
-	return cfg.ErrNotSupported
+	return false
 }
 
+// ToDuration returns the duration of a fixed period. It panics for periods
+// aligned to the wall clock.
 func (t PeriodType) ToDuration() time.Duration {
 	switch t {
 	case EverySecond:
@@ -59,3 +104,292 @@ func (t PeriodType) ToDuration() time.Duration {
 
 	panic("unsupported period type")
 }
+
+// Trigger parses a period aligned to the wall clock. It returns nil Trigger
+// for fixed periods.
+func (t PeriodType) Trigger() (*Trigger, error) {
+	if t.isFixed() {
+		return nil, nil
+	}
+
+	var (
+		tr  *Trigger
+		err error
+	)
+
+	s := strings.TrimSpace(string(t))
+	sLower := strings.ToLower(s)
+
+	switch {
+	case strings.HasPrefix(sLower, cronPrefix):
+		tr, err = parseCronTrigger(s[len(cronPrefix):])
+	case strings.HasPrefix(sLower, everyPrefix) && strings.Contains(sLower, atSeparator):
+		tr, err = parseEveryAtTrigger(s[len(everyPrefix):])
+	default:
+		err = cfg.ErrNotSupported
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tr.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("period never ends: %w", cfg.ErrInvalidValue)
+	}
+
+	return tr, nil
+}
+
+// Trigger fires at the moments of wall-clock time that match the cron-like
+// rules for minutes, hours, days of month, months and days of week.
+type Trigger struct {
+	minutes  []bool
+	hours    []bool
+	days     []bool // days of month, 1-based
+	months   []bool // 1-based
+	weekdays []bool
+
+	anyDay, anyWeekday bool
+
+	loc *time.Location
+}
+
+// Next returns the first moment the trigger fires strictly after t. It returns
+// zero time if there is no such moment in the foreseeable future.
+func (tr *Trigger) Next(t time.Time) time.Time {
+	t = t.In(tr.loc)
+	year, month, day := t.Date()
+
+	for i := 0; i < maxTriggerSearchDays; i++ {
+		date := time.Date(year, month, day+i, 0, 0, 0, 0, tr.loc)
+		if !tr.matchesDate(date) {
+			continue
+		}
+
+		for hour := range tr.hours {
+			if !tr.hours[hour] {
+				continue
+			}
+
+			for minute := range tr.minutes {
+				if !tr.minutes[minute] {
+					continue
+				}
+
+				candidate := time.Date(
+					date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, tr.loc,
+				)
+				if candidate.After(t) {
+					return candidate
+				}
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+func (tr *Trigger) matchesDate(date time.Time) bool {
+	if !tr.months[date.Month()] {
+		return false
+	}
+
+	dayMatches := tr.days[date.Day()]
+	weekdayMatches := tr.weekdays[date.Weekday()]
+
+	// same as cron, if both days of month and days of week are restricted,
+	// matching any of them is enough
+	switch {
+	case tr.anyDay:
+		return weekdayMatches
+	case tr.anyWeekday:
+		return dayMatches
+	}
+
+	return dayMatches || weekdayMatches
+}
+
+// parseEveryAtTrigger parses periods like 'monday at 10:00 Europe/Moscow'.
+func parseEveryAtTrigger(s string) (*Trigger, error) {
+	idx := strings.Index(strings.ToLower(s), atSeparator)
+	if idx < 0 {
+		return nil, cfg.ErrNotSupported
+	}
+
+	dayName, timeStr := strings.ToLower(strings.TrimSpace(s[:idx])), s[idx+len(atSeparator):]
+
+	tr := newTrigger()
+	tr.anyDay = true
+
+	fill(tr.days, 1, len(tr.days)-1, 1)
+	fill(tr.months, 1, len(tr.months)-1, 1)
+
+	if dayName == "day" {
+		fill(tr.weekdays, 0, len(tr.weekdays)-1, 1)
+	} else {
+		weekday, ok := weekdays[dayName]
+		if !ok {
+			return nil, fmt.Errorf("unknown day '%s': %w", dayName, cfg.ErrInvalidValue)
+		}
+
+		tr.weekdays[weekday] = true
+	}
+
+	fields := strings.Fields(timeStr)
+	if len(fields) == 0 || len(fields) > 2 { // nolint: gomnd
+		return nil, fmt.Errorf(
+			"time of day may only be followed by time zone: %w", cfg.ErrInvalidValue,
+		)
+	}
+
+	hour, minute, err := parseTimeOfDay(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	tr.hours[hour] = true
+	tr.minutes[minute] = true
+
+	if len(fields) > 1 {
+		if tr.loc, err = time.LoadLocation(fields[1]); err != nil {
+			return nil, fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+
+	return tr, nil
+}
+
+func parseTimeOfDay(s string) (hour, minute int, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != timeOfDayLen {
+		return 0, 0, fmt.Errorf("time of day '%s' must be HH:MM: %w", s, cfg.ErrInvalidValue)
+	}
+
+	hour, err = strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("invalid hour in '%s': %w", s, cfg.ErrInvalidValue)
+	}
+
+	minute, err = strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid minute in '%s': %w", s, cfg.ErrInvalidValue)
+	}
+
+	return hour, minute, nil
+}
+
+// parseCronTrigger parses a classic five field cron expression optionally
+// followed by a time zone, e.g. '0 10 * * 1-5 Europe/Moscow'.
+func parseCronTrigger(s string) (*Trigger, error) {
+	fields := strings.Fields(s)
+	if len(fields) != cronFieldsNum && len(fields) != cronFieldsNum+1 {
+		return nil, fmt.Errorf(
+			"cron expression must have %d fields and optional time zone: %w",
+			cronFieldsNum, cfg.ErrInvalidValue,
+		)
+	}
+
+	tr := newTrigger()
+
+	cronFields := []struct {
+		name     string
+		values   []bool
+		min, max int
+	}{
+		{"minute", tr.minutes, 0, 59},
+		{"hour", tr.hours, 0, 23},
+		{"day of month", tr.days, 1, 31},
+		{"month", tr.months, 1, 12},
+		{"day of week", tr.weekdays, 0, 7},
+	}
+
+	for i, field := range cronFields {
+		if err := parseCronField(fields[i], field.values, field.min, field.max); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", field.name, err)
+		}
+	}
+
+	tr.anyDay = fields[2] == "*"
+	tr.anyWeekday = fields[4] == "*"
+
+	if len(fields) > cronFieldsNum {
+		var err error
+
+		if tr.loc, err = time.LoadLocation(fields[cronFieldsNum]); err != nil {
+			return nil, fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+
+	return tr, nil
+}
+
+// parseCronField parses lists of values, ranges and steps like '1,3-5,*/15'.
+func parseCronField(s string, values []bool, min, max int) error {
+	for _, item := range strings.Split(s, ",") {
+		step := 1
+		rangeStr := item
+
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			var err error
+
+			if step, err = strconv.Atoi(item[idx+1:]); err != nil || step <= 0 {
+				return fmt.Errorf("step in '%s': %w", item, cfg.ErrInvalidValue)
+			}
+
+			rangeStr = item[:idx]
+		}
+
+		from, to := min, max
+
+		if rangeStr != "*" {
+			bounds := strings.SplitN(rangeStr, "-", 2) // nolint: gomnd
+
+			var err error
+
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return fmt.Errorf("'%s': %w", item, cfg.ErrInvalidValue)
+			}
+
+			to = from
+			if len(bounds) > 1 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return fmt.Errorf("'%s': %w", item, cfg.ErrInvalidValue)
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+
+		if from < min || to > max || from > to {
+			return fmt.Errorf("'%s' out of range [%d, %d]: %w", item, min, max, cfg.ErrInvalidValue)
+		}
+
+		fill(values, from, to, step)
+	}
+
+	// 7 is an alias for sunday
+	if len(values) > 7 && values[7] { // nolint: gomnd
+		values[0] = true
+	}
+
+	return nil
+}
+
+// newTrigger creates a trigger that never fires, days of month and months
+// are 1-based, sunday is both 0 and 7 like in cron.
+func newTrigger() *Trigger {
+	return &Trigger{
+		minutes:  make([]bool, 60),   // nolint: gomnd
+		hours:    make([]bool, 24),   // nolint: gomnd
+		days:     make([]bool, 31+1), // nolint: gomnd
+		months:   make([]bool, 12+1), // nolint: gomnd
+		weekdays: make([]bool, 7+1),  // nolint: gomnd
+		loc:      time.Local,
+	}
+}
+
+func fill(values []bool, from, to, step int) {
+	for i := from; i <= to; i += step {
+		values[i] = true
+	}
+}
@@ -0,0 +1,93 @@
+package dutyscheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type triggerTestcase struct {
+	period PeriodType
+	input  time.Time
+	output time.Time
+}
+
+func TestPeriodTypeTrigger(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skipf("time zone info is unavailable: %v", err)
+	}
+
+	fri := time.Date(2021, time.January, 22, 12, 30, 0, 0, moscow)
+
+	for _, testcase := range []triggerTestcase{
+		{
+			"every monday at 10:00 Europe/Moscow",
+			fri,
+			time.Date(2021, time.January, 25, 10, 0, 0, 0, moscow),
+		},
+		{
+			"every Friday at 12:30 Europe/Moscow", // strictly after
+			fri,
+			time.Date(2021, time.January, 29, 12, 30, 0, 0, moscow),
+		},
+		{
+			"every day at 9:15 Europe/Moscow",
+			fri,
+			time.Date(2021, time.January, 23, 9, 15, 0, 0, moscow),
+		},
+		{
+			"every day at 07:00 UTC",
+			fri,
+			time.Date(2021, time.January, 23, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			"cron 0 10 * * 1-5 Europe/Moscow", // weekdays
+			fri,
+			time.Date(2021, time.January, 25, 10, 0, 0, 0, moscow),
+		},
+		{
+			"cron */20 12-13 * * * Europe/Moscow",
+			fri,
+			time.Date(2021, time.January, 22, 12, 40, 0, 0, moscow),
+		},
+		{
+			"cron 0 0 1 */3 * Europe/Moscow", // quarterly
+			fri,
+			time.Date(2021, time.April, 1, 0, 0, 0, 0, moscow),
+		},
+		{
+			"cron 0 10 1 * 7 Europe/Moscow", // first day of month or sunday
+			fri,
+			time.Date(2021, time.January, 24, 10, 0, 0, 0, moscow),
+		},
+	} {
+		tr, err := testcase.period.Trigger()
+		if !assert.NoError(t, err, testcase.period) {
+			continue
+		}
+
+		assert.True(t,
+			testcase.output.Equal(tr.Next(testcase.input)),
+			"%s: expected %s, got %s", testcase.period, testcase.output, tr.Next(testcase.input),
+		)
+	}
+}
+
+func TestPeriodTypeValidate(t *testing.T) {
+	for _, period := range []PeriodType{
+		EverySecond, EveryDay, Every4Weeks,
+		"every monday at 10:00", "every day at 23:59 Europe/Moscow", "cron 0 10 * * 1",
+	} {
+		assert.NoError(t, period.Validate(), period)
+	}
+
+	for _, period := range []PeriodType{
+		"", "every year", "every someday at 10:00", "every day at 25:00", "every day at 10",
+		"every day at 10:00 Mars/Olympus", "cron 0 10 * *", "cron 60 10 * * *",
+		"cron 0 10 * * 8", "cron 0 10 5-1 * *", "cron 0 0 31 2 *",
+	} {
+		assert.Error(t, period.Validate(), period)
+	}
+}
@@ -0,0 +1,161 @@
+package dutyscheduler
+
+import (
+	"fmt"
+	"log"
+	"net/mail"
+	"sort"
+	"strings"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+)
+
+const (
+	profileDisplayNameParamName = "display_name"
+	contactsMyTeamParamName     = "myteam"
+	contactsSlackParamName      = "slack"
+	contactsTelegramParamName   = "telegram"
+	contactsEmailParamName      = "email"
+)
+
+// ContactsConfig holds the ids of an applicant that messengers use to mention them
+type ContactsConfig struct {
+	prefix string
+
+	MyTeam   string `mapstructure:"myteam"`
+	Slack    string
+	Telegram string
+	Email    string
+}
+
+func (cfg ContactsConfig) paramWithPrefix() func(name string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
+
+func (cfg *ContactsConfig) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	cfg.Telegram = strings.TrimPrefix(cfg.Telegram, "@")
+
+	if len(cfg.Email) != 0 {
+		if _, err := mail.ParseAddress(cfg.Email); err != nil {
+			return fmt.Errorf(
+				"%s '%s': %v: %w",
+				paramNameFactory(contactsEmailParamName), cfg.Email, err, cfgUtil.ErrInvalidValue,
+			)
+		}
+	}
+
+	return nil
+}
+
+func (cfg ContactsConfig) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %s", paramNameFactory(contactsMyTeamParamName), cfg.MyTeam)
+	log.Printf("%s: %s", paramNameFactory(contactsSlackParamName), cfg.Slack)
+	log.Printf("%s: %s", paramNameFactory(contactsTelegramParamName), cfg.Telegram)
+	log.Printf("%s: %s", paramNameFactory(contactsEmailParamName), cfg.Email)
+}
+
+func (cfg *ContactsConfig) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
+
+// ProfileConfig describes how an applicant is displayed in messages and how
+// messengers can mention them. It is meant for applicants given by names only,
+// applicants given as a list may have the same settings inline.
+type ProfileConfig struct {
+	prefix string
+
+	DisplayName string `mapstructure:"display_name"`
+
+	ContactsConfig `mapstructure:",squash"`
+}
+
+func (cfg ProfileConfig) paramWithPrefix() func(name string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
+
+func (cfg *ProfileConfig) Validate() error {
+	return cfg.ContactsConfig.Validate()
+}
+
+func (cfg ProfileConfig) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %s", paramNameFactory(profileDisplayNameParamName), cfg.DisplayName)
+	cfg.ContactsConfig.Print()
+}
+
+func (cfg *ProfileConfig) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+	cfg.ContactsConfig.SetPrefix(prefix)
+}
+
+// applyTo overrides the settings of the applicant with the ones set in the profile
+func (cfg ProfileConfig) applyTo(applicant *ApplicantConfig) {
+	override := func(dst *string, src string) {
+		if len(src) != 0 {
+			*dst = src
+		}
+	}
+
+	override(&applicant.DisplayName, cfg.DisplayName)
+	override(&applicant.Contacts.MyTeam, cfg.MyTeam)
+	override(&applicant.Contacts.Slack, cfg.Slack)
+	override(&applicant.Contacts.Telegram, cfg.Telegram)
+	override(&applicant.Contacts.Email, cfg.Email)
+}
+
+// validateProfiles validates profiles of the applicants and applies them,
+// every profile must belong to one of the applicants.
+func (cfg *Config) validateProfiles() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	for name, profile := range cfg.Profiles {
+		profile.SetPrefix(paramNameFactory(profilesParamName) + "." + name)
+
+		applicant := cfg.applicant(name)
+		if applicant == nil {
+			return fmt.Errorf(
+				"%s: '%s' is not an applicant: %w",
+				paramNameFactory(profilesParamName), name, cfgUtil.ErrInvalidValue,
+			)
+		}
+
+		if err := profile.Validate(); err != nil {
+			return err
+		}
+
+		profile.applyTo(applicant)
+
+		cfg.Profiles[name] = profile
+	}
+
+	return nil
+}
+
+// applicant returns the applicant with the given name, nil if there is none
+func (cfg *Config) applicant(name string) *ApplicantConfig {
+	for i := range cfg.Applicants {
+		if cfg.Applicants[i].Name == name {
+			return &cfg.Applicants[i]
+		}
+	}
+
+	return nil
+}
+
+func (cfg Config) printProfiles() {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		cfg.Profiles[name].Print()
+	}
+}
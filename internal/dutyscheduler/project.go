@@ -1,24 +1,35 @@
 package dutyscheduler
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"math"
-	"strconv"
+	"sort"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/notifychannel"
 	"github.com/gibsn/duty_bot/internal/statedumper"
 	vacationdb "github.com/gibsn/duty_bot/internal/vacationdb"
 	"github.com/sirupsen/logrus"
 )
 
 var (
-	ErrNamesDoNotMatch = errors.New("name of the given does match that of the project's")
+	ErrNamesDoNotMatch  = errors.New("name of the given does match that of the project's")
+	ErrUnknownApplicant = errors.New("unknown applicant")
+	ErrNobodyOnDuty     = errors.New("nobody is on duty yet")
+)
+
+const (
+	// protects schedule simulation against calendars consisting of day offs only
+	maxScheduleSteps = 10000
+
+	scheduleTimeFormat = "Mon 02 Jan 15:04 MST"
+	nobody             = "nobody"
 )
 
 type dayOffsDB interface {
@@ -32,11 +43,35 @@ type Project struct {
 
 	logger *logrus.Entry
 
-	dutyApplicants []string
-	currentPerson  uint64 // idx into dutyApplicants
+	dutyApplicants []string // names of active applicants
+	currentPerson  uint64   // idx into dutyApplicants
+
+	applicants map[string]ApplicantConfig // name -> applicant, inactive ones included
+	aliases    map[string]string          // name or alias -> name of an active applicant
+
+	roles []*role // roles besides the primary one
+
+	regions []*region // regions covering their windows, nil unless the project follows the sun
+	region  *region   // region on duty, nil if nobody is on duty or there are no regions
+
+	message *template.Template
+
+	substitute string            // if not empty, takes duty instead of the current person
+	swaps      map[string]string // person -> who takes their next turn
+	skips      map[string]bool   // persons whose next turn is skipped
+
+	paused bool // no changes are scheduled while paused
+
+	totals      map[string]statedumper.DutyTotals // applicant -> time on duty
+	countedTill time.Time                         // time on duty is counted in totals till this time
+	lastDuty    map[string]time.Time              // applicant -> end of their last shift
+	streak      int                               // number of shifts in a row of the current person
+
+	remindedOf map[string]time.Time // reminder -> what it has been handled for last, see reminded
 
 	timeOfLastChange time.Time // previous time the person was changed
 	period           PeriodType
+	trigger          *Trigger // if not nil, changes are aligned to the wall clock
 
 	dayOffsDB  dayOffsDB             // if not nil, use for info about dayoffs
 	vacationDB vacationdb.VacationDB // if not nil, use for info about vacations
@@ -52,7 +87,7 @@ func NewProject(name, applicants string, period PeriodType) (*Project, error) {
 
 	fakeCfg := NewConfig()
 	fakeCfg.Name = name
-	fakeCfg.Applicants = applicants
+	fakeCfg.Applicants = ParseApplicants(applicants)
 	fakeCfg.Period = periodStr
 	fakeCfg.SkipDayOffs = skipDayOffs
 	fakeCfg.Persist = statePersistence
@@ -64,6 +99,11 @@ func NewProjectFromConfig(config Config) (*Project, error) {
 	p := &Project{
 		cfg:           config,
 		currentPerson: math.MaxUint64, // so that the first NextPerson call returns the first person
+		swaps:         make(map[string]string),
+		skips:         make(map[string]bool),
+		totals:        make(map[string]statedumper.DutyTotals),
+		lastDuty:      make(map[string]time.Time),
+		remindedOf:    make(map[string]time.Time),
 		period:        PeriodType(config.Period),
 		mu:            &sync.RWMutex{},
 		logger: logrus.WithFields(map[string]interface{}{
@@ -77,12 +117,59 @@ func NewProjectFromConfig(config Config) (*Project, error) {
 		return nil, fmt.Errorf("invalid duty_applicants: %w", cfg.ErrMustNotBeEmpty)
 	}
 
-	p.dutyApplicants = append(p.dutyApplicants, strings.Split(config.Applicants, ",")...)
+	trigger, err := p.period.Trigger()
+	if err != nil {
+		return nil, fmt.Errorf("invalid period '%s': %w", p.period, err)
+	}
+
+	p.trigger = trigger
+
+	if p.message, err = newMessageTemplate(config.MessagePattern); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	p.applicants = make(map[string]ApplicantConfig, len(config.Applicants))
+	p.aliases = make(map[string]string, len(config.Applicants))
+
+	for _, applicant := range config.Applicants {
+		p.applicants[applicant.Name] = applicant
+
+		if !applicant.IsActive() {
+			continue
+		}
+
+		p.dutyApplicants = append(p.dutyApplicants, applicant.Name)
+
+		p.aliases[applicant.Name] = applicant.Name
+		for _, alias := range applicant.Aliases {
+			p.aliases[alias] = applicant.Name
+		}
+	}
+
+	for _, roleCfg := range config.Roles {
+		p.roles = append(p.roles, newRole(roleCfg))
+
+		// persons taking the role only are known by their profiles too
+		for _, applicant := range roleCfg.Applicants {
+			if _, ok := p.applicants[applicant.Name]; !ok {
+				p.applicants[applicant.Name] = applicant
+			}
+		}
+	}
 
 	if len(p.dutyApplicants) == 0 {
 		return nil, fmt.Errorf("invalid duty_applicants: %w", cfg.ErrMustNotBeEmpty)
 	}
 
+	for _, regionCfg := range config.Regions {
+		r, err := newRegion(regionCfg, p.applicants)
+		if err != nil {
+			return nil, fmt.Errorf("invalid region: %w", err)
+		}
+
+		p.regions = append(p.regions, r)
+	}
+
 	return p, nil
 }
 
@@ -94,7 +181,27 @@ func (p *Project) CurrentPerson() string {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	return p.dutyApplicants[int(p.currentPerson)%len(p.dutyApplicants)]
+	return p.currentPersonLocked()
+}
+
+func (p *Project) currentPersonLocked() string {
+	if len(p.substitute) != 0 {
+		return p.substitute
+	}
+
+	applicants, position := p.rotation(p.region)
+
+	return applicants[int(*position)%len(applicants)]
+}
+
+// Person returns the profile of the given applicant. Applicants without
+// a profile are displayed by their names.
+func (p *Project) Person(name string) notifychannel.Person {
+	if applicant, ok := p.applicants[name]; ok {
+		return applicant.person()
+	}
+
+	return notifychannel.Person{Name: name}
 }
 
 func (p *Project) LastChange() time.Time {
@@ -105,23 +212,161 @@ func (p *Project) LastChange() time.Time {
 }
 
 func (p *Project) NextPerson() string {
+	return p.nextPerson(time.Now()).person
+}
+
+// nextPerson assigns the next person of duty at the given time. It also reports
+// the applicants whose turn was passed since they are on vacation and whether
+// the person takes the turn due to a swap.
+func (p *Project) nextPerson(now time.Time) turn {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// protect against possible infinite loop
-	for personsTried := 0; personsTried < len(p.dutyApplicants); personsTried++ {
-		p.currentPerson++
-		currentPersonName := p.dutyApplicants[int(p.currentPerson)%len(p.dutyApplicants)]
+	p.countDuty(now)
 
-		if p.shouldConsiderVacations() && p.isOnVacation(currentPersonName) {
-			p.logger.Infof("%s is on vacation today, skipping", currentPersonName)
-			continue
+	s := p.turnState(false)
+
+	p.substitute = ""
+	p.setRegion(now)
+
+	applicants, position := p.rotation(p.region)
+	end := p.nextPeriod(now)
+
+	next := p.pickPerson(s, applicants, position, now, end, p.logger)
+	if len(next.person) == 0 {
+		return next
+	}
+
+	if next.substitute {
+		p.substitute = next.person
+	}
+
+	s.took(next.person, end)
+	p.streak = s.streak
+
+	p.assignRoles(next.person, now)
+
+	return next
+}
+
+// Swap exchanges the upcoming turns of the two given applicants.
+// Any previous swaps of these applicants are cancelled.
+func (p *Project) Swap(person1, person2 string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+
+	if person1, err = p.resolveApplicant(person1); err != nil {
+		return err
+	}
+
+	if person2, err = p.resolveApplicant(person2); err != nil {
+		return err
+	}
+
+	if person1 == person2 {
+		return fmt.Errorf("can not swap '%s' with themselves: %w", person1, cfg.ErrInvalidValue)
+	}
+
+	p.cancelSwap(person1)
+	p.cancelSwap(person2)
+
+	p.swaps[person1] = person2
+	p.swaps[person2] = person1
+
+	return nil
+}
+
+func (p *Project) cancelSwap(person string) {
+	substitute, ok := p.swaps[person]
+	if !ok {
+		return
+	}
+
+	delete(p.swaps, person)
+
+	if p.swaps[substitute] == person {
+		delete(p.swaps, substitute)
+	}
+}
+
+// HandOff passes the current duty to the given applicant till the next change.
+// It returns the person that was on duty before and the name of the applicant
+// the duty has been passed to.
+func (p *Project) HandOff(person string) (string, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.timeOfLastChange.IsZero() {
+		return "", "", ErrNobodyOnDuty
+	}
+
+	person, err := p.resolveApplicant(person)
+	if err != nil {
+		return "", "", err
+	}
+
+	prevPerson := p.currentPersonLocked()
+
+	if prevPerson == person {
+		return "", "", fmt.Errorf("'%s' is already on duty: %w", person, cfg.ErrInvalidValue)
+	}
+
+	p.countDuty(time.Now())
+	p.substitute = person
+	p.streak = 1
+
+	// the person can not hold two roles, so they exchange roles with the previous one
+	for _, r := range p.roles {
+		if r.holder == person {
+			r.holder = prevPerson
+			p.logger.Infof("%s takes role %s", r.holder, r.name)
 		}
+	}
 
-		return currentPersonName
+	return prevPerson, person, nil
+}
+
+// Skip makes the given applicant skip their next turn.
+func (p *Project) Skip(person string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	person, err := p.resolveApplicant(person)
+	if err != nil {
+		return err
 	}
 
-	return ""
+	p.skips[person] = true
+
+	return nil
+}
+
+// SetPaused pauses or resumes scheduled changes of the person of duty.
+func (p *Project) SetPaused(paused bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.paused = paused
+}
+
+func (p *Project) Paused() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.paused
+}
+
+// resolveApplicant returns the name of the active applicant known by the given
+// name or alias.
+func (p *Project) resolveApplicant(person string) (string, error) {
+	name, ok := p.aliases[person]
+	if !ok {
+		return "", fmt.Errorf("'%s': %w", person, ErrUnknownApplicant)
+	}
+
+	return name, nil
 }
 
 func (p *Project) SetTimeOfLastChange(t time.Time) {
@@ -139,15 +384,78 @@ func (p *Project) RestoreState(state statedumper.SchedulingState) error {
 		return fmt.Errorf("'%s' != '%s': %w", p.Name(), state.Name, ErrNamesDoNotMatch)
 	}
 
-	p.currentPerson = state.CurrentPerson
+	p.restoreRotations(state)
+	p.region = p.findRegion(state.Region)
 	p.timeOfLastChange = state.TimeOfLastChange
+	p.substitute = state.Substitute
+	p.paused = state.Paused
+
+	p.skips = make(map[string]bool, len(state.Skips))
+	for _, person := range state.Skips {
+		p.skips[person] = true
+	}
+
+	p.swaps = make(map[string]string, len(state.Swaps))
+	for person, substitute := range state.Swaps {
+		p.swaps[person] = substitute
+	}
+
+	for _, r := range p.roles {
+		r.holder = state.Roles[r.name]
+	}
+
+	p.totals = make(map[string]statedumper.DutyTotals, len(state.Totals))
+	for person, total := range state.Totals {
+		p.totals[person] = total
+	}
+
+	// states saved before duty was counted start counting from the last change
+	p.countedTill = state.CountedTill
+	if p.countedTill.IsZero() {
+		p.countedTill = state.TimeOfLastChange
+	}
+
+	p.lastDuty = make(map[string]time.Time, len(state.LastDuty))
+	for person, t := range state.LastDuty {
+		p.lastDuty[person] = t
+	}
+
+	p.streak = state.Streak
+
+	p.remindedOf = make(map[string]time.Time, len(state.RemindedOf))
+	for reminder, t := range state.RemindedOf {
+		p.remindedOf[reminder] = t
+	}
 
 	return nil
 }
 
+// restoreStateFrom restores the state of the project saved by the state dumper,
+// the project keeps its initial state in case of errors.
+func (p *Project) restoreStateFrom(stateDumper stateDumper) {
+	state, err := stateDumper.GetState(p.Name())
+	if err != nil {
+		p.logger.Errorf("could not get scheduling state from state dumper: %v", err)
+		return
+	}
+
+	if err := p.RestoreState(state); err != nil {
+		p.logger.Errorf("could not restore states: %v", err)
+		return
+	}
+
+	p.logger.Infof(
+		"successfully restored state, current person of duty is %s, last change was %s",
+		p.CurrentPerson(), p.LastChange(),
+	)
+}
+
 // ShouldChangePerson reports whether the person of duty should be changed
 // given the circumstances
 func (p *Project) ShouldChangePerson() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	return p.shouldChangePerson(time.Now())
 }
 
@@ -168,40 +476,65 @@ func (p *Project) shouldConsiderVacations() bool {
 }
 
 func (p *Project) isDayOff(t time.Time) bool {
-	if !p.shouldConsiderHolidays() {
-		return isWeekEndDay(t)
-	}
-
-	isDayOff, err := p.dayOffsDB.IsDayOff(t)
+	isDayOff, err := p.checkDayOff(t)
 	if err != nil {
 		p.logger.Errorf("could not check if %s is a day off: %v", t, err)
 		p.logger.Warnf("not considering holidays due to an error, will only consider weekends")
-
-		return isWeekEndDay(t)
 	}
 
 	return isDayOff
 }
 
-func (p *Project) isOnVacation(person string) bool {
-	isOnVacation, err := p.vacationDB.IsOnVacation(person, time.Now())
+// checkDayOff reports whether the given date is a day off. In case there is no info
+// about the date, it falls back to weekends and returns the error.
+func (p *Project) checkDayOff(t time.Time) (bool, error) {
+	if !p.shouldConsiderHolidays() {
+		return isWeekEndDay(t), nil
+	}
+
+	isDayOff, err := p.dayOffsDB.IsDayOff(t)
 	if err != nil {
-		p.logger.Errorf("could not check whether '%s' is on vacation: %v", person, err)
-		return false
+		return isWeekEndDay(t), err
 	}
 
-	return isOnVacation
+	return isDayOff, nil
+}
+
+// isOnVacation reports whether the applicant is on vacation at the given time in
+// their time zone. The applicant is looked up by the name and all the aliases.
+func (p *Project) isOnVacation(person string, t time.Time) bool {
+	applicant := p.applicants[person]
+
+	t = t.In(applicant.location())
+
+	for _, name := range append([]string{person}, applicant.Aliases...) {
+		isOnVacation, err := p.vacationDB.IsOnVacation(name, t)
+		if err != nil {
+			p.logger.Errorf("could not check whether '%s' is on vacation: %v", name, err)
+			continue
+		}
+
+		if isOnVacation {
+			return true
+		}
+	}
+
+	return false
 }
 
 // shouldChangePerson implements the main logic for ShouldChangePerson
 func (p *Project) shouldChangePerson(timeNow time.Time) bool {
+	if p.paused {
+		return false
+	}
+
 	// if restarted and it is not time to change person yet
-	if timeNow.Sub(p.timeOfLastChange) < p.period.ToDuration() {
+	if !p.isChangeDue(timeNow) {
 		return false
 	}
 
-	// no duties at day offs (yet)
-	if p.cfg.SkipDayOffs && p.isDayOff(timeNow) {
+	// no duties at day offs (yet), regions check their own day offs
+	if p.cfg.SkipDayOffs && p.regions == nil && p.isDayOff(timeNow) {
 		return false
 	}
 
@@ -209,10 +542,47 @@ func (p *Project) shouldChangePerson(timeNow time.Time) bool {
 }
 
 func (p *Project) TimeTillNextChange() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	return p.timeTillNextChange(time.Now())
 }
 
+// isChangeDue reports whether at least one period has passed since the last change
+func (p *Project) isChangeDue(timeNow time.Time) bool {
+	if p.regions != nil {
+		return p.isShiftChangeDue(timeNow)
+	}
+
+	if p.trigger != nil {
+		return !p.trigger.Next(p.timeOfLastChange).After(timeNow)
+	}
+
+	return timeNow.Sub(p.timeOfLastChange) >= p.period.ToDuration()
+}
+
+// nextPeriod returns the time when the period that starts at t ends
+func (p *Project) nextPeriod(t time.Time) time.Time {
+	if p.regions != nil {
+		return p.nextShift(t)
+	}
+
+	if p.trigger != nil {
+		return p.trigger.Next(t)
+	}
+
+	return t.Add(p.period.ToDuration())
+}
+
 func (p *Project) timeTillNextChange(timeNow time.Time) time.Duration {
+	if p.regions != nil {
+		return p.nextShift(timeNow).Sub(timeNow)
+	}
+
+	if p.trigger != nil {
+		return p.trigger.Next(timeNow).Sub(timeNow)
+	}
+
 	periodDuration := p.period.ToDuration()
 
 	nextTriggerTime := p.timeOfLastChange.Add(periodDuration)
@@ -226,23 +596,221 @@ func (p *Project) timeTillNextChange(timeNow time.Time) time.Duration {
 	return timeTillNextChange
 }
 
+// ScheduleEntry is a planned change of the person of duty
+type ScheduleEntry struct {
+	Person string
+	Start  time.Time
+	Region string // region covering the shift, empty unless the project follows the sun
+
+	SkippedForVacation []string // applicants whose turn was passed since they are on vacation
+
+	Roles map[string]string // role -> person, the primary role is not included
+}
+
+func (e ScheduleEntry) String() string {
+	person := e.Person
+	if len(person) == 0 {
+		person = nobody
+	}
+
+	s := fmt.Sprintf("%s: %s", e.Start.Format(scheduleTimeFormat), person)
+
+	if len(e.Region) != 0 {
+		s += fmt.Sprintf(" [%s]", e.Region)
+	}
+
+	if len(e.Roles) != 0 {
+		s += fmt.Sprintf(" (%s)", formatRoles(e.Roles))
+	}
+
+	if len(e.SkippedForVacation) != 0 {
+		s += fmt.Sprintf(" (%s on vacation)", strings.Join(e.SkippedForVacation, ", "))
+	}
+
+	return s
+}
+
+// Schedule simulates the next n changes of the person of duty without changing
+// the state of the project. Day offs, vacations, swaps and skips are considered the
+// same way they are considered when the person is actually changed. Note that day
+// offs and vacations databases may have no info about the distant future.
+func (p *Project) Schedule(n int) []ScheduleEntry {
+	return p.schedule(time.Now(), n)
+}
+
+func (p *Project) schedule(timeNow time.Time, n int) []ScheduleEntry {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	entries := make([]ScheduleEntry, 0, n)
+
+	// nothing is scheduled until the project is resumed
+	if p.paused {
+		return entries
+	}
+
+	s := p.turnState(true)
+
+	nextPerson := p.currentPerson
+	roleHolders := p.roleHolders()
+
+	regionPositions := make(map[*region]*uint64, len(p.regions))
+	for _, r := range p.regions {
+		position := r.position
+		regionPositions[r] = &position
+	}
+
+	// change may be overdue in case the project has just started
+	changeTime := timeNow
+	if !p.isChangeDue(timeNow) {
+		changeTime = timeNow.Add(p.timeTillNextChange(timeNow))
+	}
+
+	// the current shift lasts till the first change
+	if !p.countedTill.IsZero() && p.countedTill.Before(changeTime) {
+		p.addDuty(s.totals, s.current, p.countedTill, changeTime)
+		s.lastDuty[s.current] = changeTime
+	}
+
+	for step := 0; len(entries) < n && step < maxScheduleSteps; step++ {
+		if p.cfg.SkipDayOffs && p.regions == nil {
+			// lack of info is not reported here since it is expected for the distant future
+			if isDayOff, _ := p.checkDayOff(changeTime); isDayOff {
+				changeTime = p.nextPeriod(changeTime)
+				continue
+			}
+		}
+
+		entry := ScheduleEntry{
+			Start: changeTime,
+		}
+
+		nextChange := p.nextPeriod(changeTime)
+
+		applicants, position := p.dutyApplicants, &nextPerson
+
+		if s, ok := p.shiftAt(changeTime); ok {
+			applicants, position = s.region.applicants, regionPositions[s.region]
+			entry.Region = s.region.name
+		}
+
+		next := p.pickPerson(s, applicants, position, changeTime, nextChange, simulationLogger)
+
+		entry.Person = next.person
+		entry.SkippedForVacation = next.skippedForVacation
+
+		if len(entry.Person) != 0 && len(p.roles) != 0 {
+			entry.Roles = p.pickRoleHolders(
+				entry.Person, applicants, *position, roleHolders, false, changeTime,
+			)
+			roleHolders = entry.Roles
+		}
+
+		if len(entry.Person) != 0 {
+			p.addDuty(s.totals, entry.Person, changeTime, nextChange)
+			s.took(entry.Person, nextChange)
+		}
+
+		entries = append(entries, entry)
+		changeTime = nextChange
+	}
+
+	return entries
+}
+
+// FormatSchedule returns a human readable schedule of the next n changes
+// starting with the current person of duty.
+func (p *Project) FormatSchedule(n int) string {
+	buf := strings.Builder{}
+
+	buf.WriteString(fmt.Sprintf("now: %s", p.currentPersonOrNobody()))
+
+	if roles := p.Roles(); len(roles) != 0 {
+		buf.WriteString(fmt.Sprintf(" (%s)", formatRoles(roles)))
+	}
+
+	schedule := p.Schedule(n)
+	if len(schedule) == 0 {
+		buf.WriteString("\nno changes are scheduled")
+	}
+
+	for _, entry := range schedule {
+		buf.WriteRune('\n')
+		buf.WriteString(entry.String())
+	}
+
+	return buf.String()
+}
+
+func (p *Project) currentPersonOrNobody() string {
+	// no one has been assigned yet
+	if p.LastChange().IsZero() {
+		return nobody
+	}
+
+	return p.CurrentPerson()
+}
+
+// ApplicantsCount returns the number of persons taking duty in turn
+func (p *Project) ApplicantsCount() int {
+	return len(p.dutyApplicants)
+}
+
 func (p *Project) DumpState(w io.StringWriter) error {
-	buf := bytes.NewBuffer(nil)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 
-	buf.WriteString(p.Name())
-	buf.WriteRune('\n')
-	buf.WriteString(strconv.Itoa(int(p.currentPerson)))
-	buf.WriteRune('\n')
-	buf.WriteString(strconv.Itoa(int(p.timeOfLastChange.Unix())))
-	buf.WriteRune('\n')
+	s, err := statedumper.FormatState(p.schedulingState())
+	if err != nil {
+		return err
+	}
 
-	if err := writeFull(w, buf.String()); err != nil {
+	if err := writeFull(w, s); err != nil {
 		return fmt.Errorf("could not write: %w", err)
 	}
 
 	return nil
 }
 
+// schedulingState returns the state of the project to be saved
+func (p *Project) schedulingState() statedumper.SchedulingState {
+	skips := make([]string, 0, len(p.skips))
+	for person := range p.skips {
+		skips = append(skips, person)
+	}
+
+	sort.Strings(skips)
+
+	state := statedumper.SchedulingState{
+		Name:             p.Name(),
+		CurrentPerson:    p.currentPerson,
+		TimeOfLastChange: p.timeOfLastChange,
+		Substitute:       p.substitute,
+		Swaps:            p.swaps,
+		Skips:            skips,
+		Paused:           p.paused,
+		Roles:            p.roleHolders(),
+		Totals:           p.totals,
+		CountedTill:      p.countedTill,
+		LastDuty:         p.lastDuty,
+		Streak:           p.streak,
+		CurrentName:      p.currentName(),
+		Rotation:         p.dutyApplicants,
+		RemindedOf:       p.remindedOf,
+	}
+
+	if len(p.regions) != 0 {
+		state.RegionPositions = p.regionPositions()
+		state.RegionRotations = p.regionRotations()
+	}
+
+	if p.region != nil {
+		state.Region = p.region.name
+	}
+
+	return state
+}
+
 func isWeekEndDay(t time.Time) bool {
 	if t.Weekday() == time.Sunday || t.Weekday() == time.Saturday {
 		return true
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -103,17 +104,23 @@ func TestProjectRestoreState(t *testing.T) {
 
 	testcases := []restoreStateTestCase{
 		{
-			statedumper.SchedulingState{"test_project", 0, time.Now().Add(-time.Hour)},
+			statedumper.SchedulingState{
+				Name: "test_project", CurrentPerson: 0, TimeOfLastChange: time.Now().Add(-time.Hour),
+			},
 			secondPerson,
 			true,
 		},
 		{
-			statedumper.SchedulingState{"test_project", 1, time.Now().Add(-time.Hour)},
+			statedumper.SchedulingState{
+				Name: "test_project", CurrentPerson: 1, TimeOfLastChange: time.Now().Add(-time.Hour),
+			},
 			firstPerson,
 			true,
 		},
 		{
-			statedumper.SchedulingState{"test_project", 1, time.Now().Add(-time.Second)},
+			statedumper.SchedulingState{
+				Name: "test_project", CurrentPerson: 1, TimeOfLastChange: time.Now().Add(-time.Second),
+			},
 			firstPerson,
 			false,
 		},
@@ -147,7 +154,11 @@ func TestProjectRestoreState(t *testing.T) {
 
 func TestProjectRestoreStateFails(t *testing.T) {
 	testcases := []restoreStateTestCase{
-		{input: statedumper.SchedulingState{"some_other_name", 0, time.Now().Add(-time.Hour)}},
+		{
+			input: statedumper.SchedulingState{
+				Name: "some_other_name", CurrentPerson: 0, TimeOfLastChange: time.Now().Add(-time.Hour),
+			},
+		},
 	}
 
 	for _, testcase := range testcases {
@@ -347,3 +358,386 @@ func TestTimeTillNextChange(t *testing.T) {
 		}
 	}
 }
+
+func TestProjectSchedule(t *testing.T) {
+	project, _ := NewProject("test_project", applicants2, EveryDay)
+
+	applicantsParsed := strings.Split(applicants2, ",")
+	firstPerson, secondPerson := applicantsParsed[0], applicantsParsed[1]
+
+	timeNow := time.Unix(1612629060, 0) // Sat Feb  6 19:31:00 MSK 2021
+
+	// first change must happen immediately
+	project.timeOfLastChange = time.Time{}
+
+	schedule := project.schedule(timeNow, 3)
+	expected := []ScheduleEntry{
+		{Person: firstPerson, Start: timeNow},
+		{Person: secondPerson, Start: timeNow.Add(EveryDay.ToDuration())},
+		{Person: firstPerson, Start: timeNow.Add(2 * EveryDay.ToDuration())},
+	}
+
+	if !reflect.DeepEqual(schedule, expected) {
+		t.Errorf("expected '%v', got '%v'", expected, schedule)
+	}
+
+	project.currentPerson = 0
+	project.timeOfLastChange = timeNow.Add(-time.Hour)
+
+	schedule = project.schedule(timeNow, 1)
+	expected = []ScheduleEntry{
+		{Person: secondPerson, Start: timeNow.Add(23 * time.Hour)},
+	}
+
+	if !reflect.DeepEqual(schedule, expected) {
+		t.Errorf("expected '%v', got '%v'", expected, schedule)
+	}
+}
+
+func TestProjectSwap(t *testing.T) {
+	project, _ := NewProject("test_project", "test1,test2,test3", EveryDay)
+
+	if err := project.Swap("test1", "test2"); err != nil {
+		t.Fatalf("could not swap: %v", err)
+	}
+
+	for _, testcase := range []struct{ input, output string }{
+		{"test1", ""}, {"test2", ""}, {"test1", "test4"},
+	} {
+		if err := project.Swap(testcase.input, testcase.output); err == nil {
+			t.Errorf("testcase '%v': must have failed", testcase)
+		}
+	}
+
+	for i, expected := range []string{"test2", "test1", "test3", "test1", "test2"} {
+		if nextPerson := project.NextPerson(); nextPerson != expected {
+			t.Errorf("call %d: expected '%s', got '%s'", i, expected, nextPerson)
+		}
+		if currentPerson := project.CurrentPerson(); currentPerson != expected {
+			t.Errorf("call %d: expected current '%s', got '%s'", i, expected, currentPerson)
+		}
+	}
+}
+
+func TestProjectSwapWithVacation(t *testing.T) {
+	project, _ := NewProject("test_project", "test1,test2,test3", EveryDay)
+
+	if err := project.Swap("test1", "test2"); err != nil {
+		t.Fatalf("could not swap: %v", err)
+	}
+
+	// the substitute is on vacation on the first day only
+	now := time.Date(2022, time.March, 1, 10, 0, 0, 0, time.Local)
+	project.SetVacationDB(calendarVacationDB{"test2 2022-03-01": true})
+
+	// test1 takes their own turn, the swap waits for their next one
+	for i, expected := range []string{"test1", "test1", "test3", "test2", "test2"} {
+		next := project.nextPerson(now.AddDate(0, 0, i))
+
+		if next.person != expected {
+			t.Errorf("day %d: expected '%s', got '%s'", i, expected, next.person)
+		}
+		if len(next.skippedForVacation) != 0 {
+			t.Errorf("day %d: nobody must be skipped, got %v", i, next.skippedForVacation)
+		}
+	}
+}
+
+func TestProjectHandOff(t *testing.T) {
+	project, _ := NewProject("test_project", "test1,test2,test3", EveryDay)
+
+	if _, _, err := project.HandOff("test2"); err == nil {
+		t.Errorf("hand off must have failed since nobody is on duty")
+	}
+
+	project.SetTimeOfLastChange(time.Now())
+	project.NextPerson()
+
+	if _, _, err := project.HandOff("test1"); err == nil {
+		t.Errorf("hand off to the current person must have failed")
+	}
+
+	prevPerson, newPerson, err := project.HandOff("test3")
+	if err != nil {
+		t.Fatalf("could not hand off: %v", err)
+	}
+
+	if prevPerson != "test1" {
+		t.Errorf("expected previous person 'test1', got '%s'", prevPerson)
+	}
+	if newPerson != "test3" {
+		t.Errorf("expected new person 'test3', got '%s'", newPerson)
+	}
+	if currentPerson := project.CurrentPerson(); currentPerson != "test3" {
+		t.Errorf("expected 'test3', got '%s'", currentPerson)
+	}
+
+	// hand off must not affect the rotation order
+	if nextPerson := project.NextPerson(); nextPerson != "test2" {
+		t.Errorf("expected 'test2', got '%s'", nextPerson)
+	}
+}
+
+func TestProjectDumpStateWithSwaps(t *testing.T) {
+	project, _ := NewProject("test_project", "test1,test2,test3", EveryHour)
+
+	project.SetTimeOfLastChange(time.Now().Truncate(time.Second))
+	project.NextPerson()
+
+	if err := project.Swap("test2", "test3"); err != nil {
+		t.Fatalf("could not swap: %v", err)
+	}
+	if _, _, err := project.HandOff("test2"); err != nil {
+		t.Fatalf("could not hand off: %v", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := project.DumpState(buf); err != nil {
+		t.Fatalf("could not dump state: %v", err)
+	}
+
+	state, err := statedumper.NewSchedulingState(buf)
+	if err != nil {
+		t.Fatalf("could not parse state: %v", err)
+	}
+
+	restoredProject, _ := NewProject("test_project", "test1,test2,test3", EveryHour)
+
+	if err := restoredProject.RestoreState(state); err != nil {
+		t.Fatalf("could not restore state: %v", err)
+	}
+
+	if currentPerson := restoredProject.CurrentPerson(); currentPerson != "test2" {
+		t.Errorf("expected 'test2', got '%s'", currentPerson)
+	}
+
+	for i, expected := range []string{"test3", "test2", "test1"} {
+		if nextPerson := restoredProject.NextPerson(); nextPerson != expected {
+			t.Errorf("call %d: expected '%s', got '%s'", i, expected, nextPerson)
+		}
+	}
+}
+
+func TestProjectSkip(t *testing.T) {
+	project, _ := NewProject("test_project", "test1,test2,test3", EveryDay)
+
+	if err := project.Skip("test4"); err == nil {
+		t.Errorf("skip of unknown applicant must have failed")
+	}
+	if err := project.Skip("test2"); err != nil {
+		t.Fatalf("could not skip: %v", err)
+	}
+
+	for i, expected := range []string{"test1", "test3", "test1", "test2"} {
+		if nextPerson := project.NextPerson(); nextPerson != expected {
+			t.Errorf("call %d: expected '%s', got '%s'", i, expected, nextPerson)
+		}
+	}
+}
+
+func TestProjectPaused(t *testing.T) {
+	project, _ := NewProject("test_project", applicants1, EverySecond)
+
+	timeNow := time.Unix(1611266369, 0) // Fri Jan 22 00:59:29 MSK 2021
+	project.timeOfLastChange = timeNow.Add(-time.Minute)
+
+	project.SetPaused(true)
+
+	if project.shouldChangePerson(timeNow) {
+		t.Errorf("person must not be changed while paused")
+	}
+
+	project.SetPaused(false)
+
+	if !project.shouldChangePerson(timeNow) {
+		t.Errorf("person must be changed after resume")
+	}
+}
+
+func TestProjectScheduleWithDayOffsAndVacations(t *testing.T) {
+	project, _ := NewProject("test_project", "test1,test2,test3", EveryDay)
+	project.cfg.SkipDayOffs = true
+
+	project.SetDayOffsDB(dummyDayOffDB{})
+	project.SetVacationDB(dummyVacationDB{true, "test2"})
+
+	timeNow := time.Unix(1609416000, 0) // Thu Dec 31 15:00:00 MSK 2020
+
+	project.currentPerson = 0
+	project.timeOfLastChange = timeNow.Add(-time.Hour)
+
+	// January 1st is a holiday, January 2nd and 3rd are weekends
+	day := EveryDay.ToDuration()
+	expected := []ScheduleEntry{
+		{Person: "test3", Start: timeNow.Add(4*day - time.Hour), SkippedForVacation: []string{"test2"}},
+		{Person: "test1", Start: timeNow.Add(5*day - time.Hour)},
+	}
+
+	schedule := project.schedule(timeNow, 2)
+	if !reflect.DeepEqual(schedule, expected) {
+		t.Errorf("expected '%v', got '%v'", expected, schedule)
+	}
+
+	// simulation must not affect the state
+	if project.currentPerson != 0 {
+		t.Errorf("state must not have changed")
+	}
+
+	project.SetPaused(true)
+
+	if schedule = project.schedule(timeNow, 2); len(schedule) != 0 {
+		t.Errorf("nothing must be scheduled while paused, got '%v'", schedule)
+	}
+}
+
+func TestProjectAlignedPeriod(t *testing.T) {
+	project, err := NewProject("test_project", applicants2, "every monday at 10:00 UTC")
+	if err != nil {
+		t.Fatalf("could not create project: %v", err)
+	}
+
+	monday := time.Date(2021, time.January, 25, 10, 0, 0, 0, time.UTC)
+
+	// started in the middle of the week
+	project.timeOfLastChange = monday.Add(-3 * 24 * time.Hour)
+
+	if project.shouldChangePerson(monday.Add(-time.Minute)) {
+		t.Errorf("person must not be changed before monday 10:00")
+	}
+	if !project.shouldChangePerson(monday) {
+		t.Errorf("person must be changed at monday 10:00")
+	}
+
+	project.timeOfLastChange = monday
+
+	if project.shouldChangePerson(monday.Add(6 * 24 * time.Hour)) {
+		t.Errorf("person must not be changed till the next monday")
+	}
+
+	timeNow := monday.Add(2 * time.Hour)
+
+	if output := project.timeTillNextChange(timeNow); output != 7*24*time.Hour-2*time.Hour {
+		t.Errorf("expected '%v', got '%v'", 7*24*time.Hour-2*time.Hour, output)
+	}
+
+	schedule := project.schedule(timeNow, 2)
+	if len(schedule) != 2 ||
+		!schedule[0].Start.Equal(monday.Add(7*24*time.Hour)) ||
+		!schedule[1].Start.Equal(monday.Add(14*24*time.Hour)) {
+		t.Errorf("unexpected schedule '%v'", schedule)
+	}
+}
+
+// newTestProject validates the config and creates the project of it
+func newTestProject(t *testing.T, config Config) *Project {
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	project, err := NewProjectFromConfig(config)
+	if err != nil {
+		t.Fatalf("could not create project: %v", err)
+	}
+
+	return project
+}
+
+func dumpTestState(t *testing.T, project *Project) string {
+	buf := &strings.Builder{}
+
+	if err := project.DumpState(buf); err != nil {
+		t.Fatalf("could not dump state: %v", err)
+	}
+
+	return buf.String()
+}
+
+func restoreTestState(t *testing.T, project *Project, s string) {
+	state, err := statedumper.NewSchedulingState(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("could not parse state: %v", err)
+	}
+
+	if err := project.RestoreState(state); err != nil {
+		t.Fatalf("could not restore state: %v", err)
+	}
+}
+
+func TestProjectStateRoundTrip(t *testing.T) {
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3,test4"),
+		Roles:      []RoleConfig{{Name: "backup"}},
+		Period:     string(EveryDay),
+	}
+
+	project := newTestProject(t, config)
+
+	project.SetTimeOfLastChange(time.Now())
+	project.NextPerson()
+	project.NextPerson()
+	project.countedTill = project.countedTill.Add(-time.Hour)
+
+	if err := project.Swap("test3", "test4"); err != nil {
+		t.Fatalf("could not swap: %v", err)
+	}
+	if err := project.Skip("test1"); err != nil {
+		t.Fatalf("could not skip: %v", err)
+	}
+	if _, _, err := project.HandOff("test4"); err != nil {
+		t.Fatalf("could not hand off: %v", err)
+	}
+
+	project.SetPaused(true)
+
+	dumped := dumpTestState(t, project)
+
+	restored := newTestProject(t, config)
+	restoreTestState(t, restored, dumped)
+
+	if redumped := dumpTestState(t, restored); redumped != dumped {
+		t.Errorf("state has changed after restore:\n%s\nexpected:\n%s", redumped, dumped)
+	}
+
+	if !strings.Contains(dumped, fmt.Sprintf(`"version": %d`, statedumper.SchemaVersion)) {
+		t.Errorf("state must have a schema version:\n%s", dumped)
+	}
+
+	// test3 and test4 swap their turns, test1 skips their own
+	for i, expected := range []string{"test4", "test3", "test2"} {
+		if nextPerson := restored.NextPerson(); nextPerson != expected {
+			t.Errorf("call %d: expected '%s', got '%s'", i, expected, nextPerson)
+		}
+	}
+}
+
+func TestProjectRestoreLegacyState(t *testing.T) {
+	project, _ := NewProject("test_project", "test1,test2,test3", EveryDay)
+
+	restoreTestState(t, project, "test_project\n1\n1609074301\n\ntest3=test1\ntest1\ntrue\n")
+
+	if person := project.CurrentPerson(); person != "test2" {
+		t.Errorf("expected 'test2', got '%s'", person)
+	}
+	if !project.Paused() {
+		t.Errorf("project must be paused")
+	}
+
+	// the legacy state is saved in the current format
+	migrated := dumpTestState(t, project)
+
+	restored, _ := NewProject("test_project", "test1,test2,test3", EveryDay)
+	restoreTestState(t, restored, migrated)
+
+	if redumped := dumpTestState(t, restored); redumped != migrated {
+		t.Errorf("state has changed after restore:\n%s\nexpected:\n%s", redumped, migrated)
+	}
+
+	// test1 takes the turn of test3 and skips their own
+	for i, expected := range []string{"test1", "test2", "test3"} {
+		if nextPerson := restored.NextPerson(); nextPerson != expected {
+			t.Errorf("call %d: expected '%s', got '%s'", i, expected, nextPerson)
+		}
+	}
+}
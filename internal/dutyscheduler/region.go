@@ -0,0 +1,437 @@
+package dutyscheduler
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/productioncal"
+)
+
+const (
+	regionNameParamName       = "name"
+	regionWindowParamName     = "window"
+	regionTimezoneParamName   = "timezone"
+	regionCountryParamName    = "country"
+	regionApplicantsParamName = "applicants"
+)
+
+const (
+	windowSeparator = "-"
+
+	// how many days ahead the next working window of a region is looked for
+	maxWindowLookahead = 31
+	// protects the search of the next shift against calendars consisting of day offs only
+	maxShiftBoundaries = 1000
+)
+
+// RegionConfig configures a region of a follow-the-sun project. The applicants
+// of the region take turns covering its working hours given by the window.
+type RegionConfig struct {
+	prefix string
+
+	Name       string
+	Window     string   // HH:MM-HH:MM in the time zone of the region, may cross midnight
+	Timezone   string   // local by default
+	Country    string   // production calendar of the region, the default one if empty
+	Applicants []string // names of the project applicants covering the region
+}
+
+func (cfg RegionConfig) paramWithPrefix() func(name string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
+
+func (cfg *RegionConfig) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	if len(cfg.Name) == 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(regionNameParamName), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+
+	if _, _, _, err := parseWindow(cfg.Window); err != nil {
+		return fmt.Errorf("%s: %w", paramNameFactory(regionWindowParamName), err)
+	}
+
+	if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+		return fmt.Errorf(
+			"%s '%s': %v: %w",
+			paramNameFactory(regionTimezoneParamName), cfg.Timezone, err, cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	if len(cfg.Country) != 0 {
+		if err := productioncal.ValidateCountry(cfg.Country); err != nil {
+			return fmt.Errorf("%s: %w", paramNameFactory(regionCountryParamName), err)
+		}
+	}
+
+	if len(cfg.Applicants) == 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(regionApplicantsParamName), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+
+	return nil
+}
+
+func (cfg RegionConfig) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %s", paramNameFactory(regionNameParamName), cfg.Name)
+	log.Printf("%s: %s", paramNameFactory(regionWindowParamName), cfg.Window)
+	log.Printf("%s: %s", paramNameFactory(regionTimezoneParamName), cfg.Timezone)
+	log.Printf("%s: %s", paramNameFactory(regionCountryParamName), cfg.Country)
+	log.Printf(
+		"%s: %s",
+		paramNameFactory(regionApplicantsParamName), strings.Join(cfg.Applicants, applicantsSeparator),
+	)
+}
+
+func (cfg *RegionConfig) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
+
+// validateRegions validates the regions of a follow-the-sun project. Every region
+// consists of the project applicants, an applicant may belong to one region only.
+func (cfg *Config) validateRegions() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	if len(cfg.Regions) == 0 {
+		return nil
+	}
+
+	if len(cfg.Period) != 0 {
+		return fmt.Errorf(
+			"%s is not used with %s: %w",
+			paramNameFactory(periodParamName), paramNameFactory(regionsParamName),
+			cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	names := make(map[string]bool, len(cfg.Regions))
+	regionOf := make(map[string]string)
+
+	for i := range cfg.Regions {
+		region := &cfg.Regions[i]
+		region.SetPrefix(fmt.Sprintf("%s[%d]", paramNameFactory(regionsParamName), i))
+
+		if err := region.Validate(); err != nil {
+			return err
+		}
+
+		if names[region.Name] {
+			return fmt.Errorf(
+				"%s: region '%s' is listed twice: %w",
+				paramNameFactory(regionsParamName), region.Name, cfgUtil.ErrInvalidValue,
+			)
+		}
+
+		names[region.Name] = true
+
+		for _, name := range region.Applicants {
+			if cfg.applicant(name) == nil {
+				return fmt.Errorf(
+					"%s: '%s' is not an applicant: %w",
+					region.paramWithPrefix()(regionApplicantsParamName), name, cfgUtil.ErrInvalidValue,
+				)
+			}
+
+			if other, ok := regionOf[name]; ok {
+				return fmt.Errorf(
+					"%s: '%s' already belongs to region '%s': %w",
+					region.paramWithPrefix()(regionApplicantsParamName), name, other,
+					cfgUtil.ErrInvalidValue,
+				)
+			}
+
+			regionOf[name] = region.Name
+		}
+	}
+
+	return nil
+}
+
+// Countries returns the production calendars the regions of the project need
+// besides the default one
+func (cfg Config) Countries() []string {
+	var countries []string
+
+	for _, region := range cfg.Regions {
+		if len(region.Country) != 0 {
+			countries = append(countries, region.Country)
+		}
+	}
+
+	return countries
+}
+
+// parseWindow parses a window of working hours given as HH:MM-HH:MM. Window
+// ending not later than it starts lasts till the next day.
+func parseWindow(s string) (hour, minute int, length time.Duration, err error) {
+	parts := strings.Split(s, windowSeparator)
+	if len(parts) != 2 { // nolint: gomnd
+		return 0, 0, 0, fmt.Errorf(
+			"window '%s' must be HH:MM-HH:MM: %w", s, cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	hour, minute, err = parseTimeOfDay(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	endHour, endMinute, err := parseTimeOfDay(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	length = time.Duration(endHour-hour)*time.Hour + time.Duration(endMinute-minute)*time.Minute
+	if length <= 0 {
+		length += 24 * time.Hour
+	}
+
+	return hour, minute, length, nil
+}
+
+// region is a region of a follow-the-sun project along with the state of its rotation
+type region struct {
+	name    string
+	loc     *time.Location
+	country string
+
+	hour, minute int // start of the window
+	length       time.Duration
+
+	applicants []string // names of active applicants
+	position   uint64   // idx into applicants
+}
+
+func newRegion(cfg RegionConfig, applicants map[string]ApplicantConfig) (*region, error) {
+	r := &region{
+		name:     cfg.Name,
+		country:  cfg.Country,
+		position: math.MaxUint64, // so that the first turn is taken by the first person
+	}
+
+	var err error
+
+	if r.hour, r.minute, r.length, err = parseWindow(cfg.Window); err != nil {
+		return nil, err
+	}
+
+	if r.loc, err = time.LoadLocation(cfg.Timezone); err != nil {
+		return nil, fmt.Errorf("invalid time zone '%s': %w", cfg.Timezone, err)
+	}
+
+	for _, name := range cfg.Applicants {
+		if applicant, ok := applicants[name]; ok && applicant.IsActive() {
+			r.applicants = append(r.applicants, name)
+		}
+	}
+
+	if len(r.applicants) == 0 {
+		return nil, fmt.Errorf(
+			"region '%s' has no active applicants: %w", r.name, cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+
+	return r, nil
+}
+
+// windowStart returns the start of the window of the region on the day that
+// is the given number of days away from the day of t in the region
+func (r *region) windowStart(t time.Time, days int) time.Time {
+	year, month, day := t.In(r.loc).Date()
+
+	return time.Date(year, month, day+days, r.hour, r.minute, 0, 0, r.loc)
+}
+
+// shift is a window of a region covered by a single person
+type shift struct {
+	region *region
+	start  time.Time
+}
+
+func (s shift) equal(other shift) bool {
+	return s.region == other.region && s.start.Equal(other.start)
+}
+
+// regionalDayOffsDB knows day offs of other countries besides the default one
+type regionalDayOffsDB interface {
+	IsDayOffIn(country string, t time.Time) (bool, error)
+}
+
+// checkDayOffIn reports whether the date of t in the region is a day off according
+// to the calendar of the region. It falls back the same way checkDayOff does.
+func (p *Project) checkDayOffIn(r *region, t time.Time) (bool, error) {
+	t = t.In(r.loc)
+
+	if db, ok := p.dayOffsDB.(regionalDayOffsDB); ok && len(r.country) != 0 {
+		isDayOff, err := db.IsDayOffIn(r.country, t)
+		if err != nil {
+			return isWeekEndDay(t), err
+		}
+
+		return isDayOff, nil
+	}
+
+	return p.checkDayOff(t)
+}
+
+// regionWorks reports whether the region covers its window starting at the given time,
+// regions do not work at their day offs if the project skips day offs
+func (p *Project) regionWorks(r *region, start time.Time) bool {
+	if !p.cfg.SkipDayOffs {
+		return true
+	}
+
+	// lack of info is not reported here since it is expected for the distant future
+	isDayOff, _ := p.checkDayOffIn(r, start)
+
+	return !isDayOff
+}
+
+// shiftAt returns the shift on duty at the given time: the window of a working
+// region covering the time, the one that has started the last if windows overlap.
+// Outside of all the windows the duty belongs to the window that starts next.
+func (p *Project) shiftAt(t time.Time) (shift, bool) {
+	var current, upcoming shift
+
+	for _, r := range p.regions {
+		for days := -1; days <= maxWindowLookahead; days++ {
+			start := r.windowStart(t, days)
+			if !p.regionWorks(r, start) {
+				continue
+			}
+
+			if !start.After(t) {
+				end := start.Add(r.length)
+				if t.Before(end) && (current.region == nil || start.After(current.start)) {
+					current = shift{region: r, start: start}
+				}
+
+				continue
+			}
+
+			if upcoming.region == nil || start.Before(upcoming.start) {
+				upcoming = shift{region: r, start: start}
+			}
+
+			break
+		}
+	}
+
+	if current.region != nil {
+		return current, true
+	}
+
+	return upcoming, upcoming.region != nil
+}
+
+// nextWindowBoundary returns the closest start or end of a window after t
+func (p *Project) nextWindowBoundary(t time.Time) time.Time {
+	var next time.Time
+
+	for _, r := range p.regions {
+		for days := -1; days <= 1; days++ {
+			start := r.windowStart(t, days)
+
+			for _, boundary := range []time.Time{start, start.Add(r.length)} {
+				if boundary.After(t) && (next.IsZero() || boundary.Before(next)) {
+					next = boundary
+				}
+			}
+		}
+	}
+
+	return next
+}
+
+// nextShift returns the time the shift on duty at t is handed over to another one
+func (p *Project) nextShift(t time.Time) time.Time {
+	current, _ := p.shiftAt(t)
+
+	boundary := t
+
+	for i := 0; i < maxShiftBoundaries; i++ {
+		boundary = p.nextWindowBoundary(boundary)
+
+		if next, ok := p.shiftAt(boundary); ok && !next.equal(current) {
+			break
+		}
+	}
+
+	return boundary
+}
+
+// isShiftChangeDue reports whether another shift has started since the last change
+func (p *Project) isShiftChangeDue(timeNow time.Time) bool {
+	if p.timeOfLastChange.IsZero() {
+		return true
+	}
+
+	last, _ := p.shiftAt(p.timeOfLastChange)
+	current, ok := p.shiftAt(timeNow)
+
+	return ok && !current.equal(last)
+}
+
+// rotation returns the applicants taking turns in the given region, the ones of
+// the project if the region is nil, and the position of the person on duty
+func (p *Project) rotation(r *region) ([]string, *uint64) {
+	if r == nil {
+		return p.dutyApplicants, &p.currentPerson
+	}
+
+	return r.applicants, &r.position
+}
+
+// setRegion makes the region on duty at t take the duty. Projects without
+// regions are not affected.
+func (p *Project) setRegion(t time.Time) {
+	s, ok := p.shiftAt(t)
+	if !ok || s.region == p.region {
+		return
+	}
+
+	p.logger.Infof("region %s takes duty", s.region.name)
+	p.region = s.region
+}
+
+// Region returns the name of the region on duty, empty unless the project
+// follows the sun
+func (p *Project) Region() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.region == nil {
+		return ""
+	}
+
+	return p.region.name
+}
+
+func (p *Project) findRegion(name string) *region {
+	for _, r := range p.regions {
+		if r.name == name {
+			return r
+		}
+	}
+
+	return nil
+}
+
+func (p *Project) regionPositions() map[string]uint64 {
+	positions := make(map[string]uint64, len(p.regions))
+
+	for _, r := range p.regions {
+		positions[r.name] = r.position
+	}
+
+	return positions
+}
@@ -0,0 +1,163 @@
+package dutyscheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+// regionalDayOffsDBMock has weekends as day offs in every country besides the given dates
+type regionalDayOffsDBMock map[string][]time.Time // country -> extra day offs
+
+func (db regionalDayOffsDBMock) IsDayOff(t time.Time) (bool, error) {
+	return isWeekEndDay(t), nil
+}
+
+func (db regionalDayOffsDBMock) IsDayOffIn(country string, t time.Time) (bool, error) {
+	for _, dayOff := range db[country] {
+		if sameDay(t, dayOff) {
+			return true, nil
+		}
+	}
+
+	return isWeekEndDay(t), nil
+}
+
+func newTestRegionsConfig() Config {
+	return Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("emea1,emea2,amer1,amer2"),
+		Regions: []RegionConfig{
+			{
+				Name: "emea", Window: "09:00-18:00", Timezone: "Europe/Moscow",
+				Applicants: []string{"emea1", "emea2"},
+			},
+			{
+				Name: "amer", Window: "09:00-18:00", Timezone: "America/New_York", Country: "us",
+				Applicants: []string{"amer1", "amer2"},
+			},
+		},
+	}
+}
+
+func formatTestSchedule(schedule []ScheduleEntry) string {
+	entries := make([]string, 0, len(schedule))
+
+	for _, entry := range schedule {
+		entries = append(entries, entry.Start.UTC().Format("Mon 15:04")+" "+entry.Person)
+	}
+
+	return strings.Join(entries, ", ")
+}
+
+func TestProjectRegionsHandOver(t *testing.T) {
+	project := newTestProject(t, newTestRegionsConfig())
+
+	// emea works 06:00-15:00 UTC, amer works 14:00-23:00 UTC in winter
+	now := time.Date(2024, time.January, 8, 5, 0, 0, 0, time.UTC)
+
+	schedule := project.schedule(now, 5)
+
+	// nobody covers the night, so emea takes over as soon as amer finishes
+	assert.Equal(t,
+		"Mon 05:00 emea1, Mon 14:00 amer1, Mon 23:00 emea2, Tue 14:00 amer2, Tue 23:00 emea1",
+		formatTestSchedule(schedule),
+	)
+	assert.Equal(t, "emea", schedule[0].Region)
+	assert.Equal(t, "amer", schedule[1].Region)
+
+	project.timeOfLastChange = now
+
+	assert.False(t, project.isChangeDue(now.Add(8*time.Hour)))
+	assert.True(t, project.isChangeDue(now.Add(9*time.Hour)))
+	assert.Equal(t, 9*time.Hour, project.timeTillNextChange(now))
+}
+
+func TestProjectRegionsDayOffs(t *testing.T) {
+	config := newTestRegionsConfig()
+	config.SkipDayOffs = true
+
+	project := newTestProject(t, config)
+
+	// a holiday in the US only
+	project.SetDayOffsDB(regionalDayOffsDBMock{
+		"us": {time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)},
+	})
+
+	now := time.Date(2024, time.January, 12, 5, 0, 0, 0, time.UTC)
+
+	assert.Equal(t,
+		"Fri 05:00 emea1, Fri 14:00 amer1, Fri 23:00 emea2, Mon 15:00 emea1, Tue 14:00 amer2",
+		formatTestSchedule(project.schedule(now, 5)),
+	)
+}
+
+func TestProjectRegionsState(t *testing.T) {
+	project := newTestProject(t, newTestRegionsConfig())
+
+	project.region = project.regions[1]
+	project.regions[1].position = 1
+	project.timeOfLastChange = time.Unix(1704722400, 0)
+
+	assert.Equal(t, "amer2", project.CurrentPerson())
+	assert.Equal(t, "amer", project.Region())
+
+	buf := &strings.Builder{}
+	assert.NoError(t, project.DumpState(buf))
+
+	state, err := statedumper.NewSchedulingState(strings.NewReader(buf.String()))
+	assert.NoError(t, err)
+
+	restored := newTestProject(t, newTestRegionsConfig())
+	assert.NoError(t, restored.RestoreState(state))
+
+	assert.Equal(t, "amer2", restored.CurrentPerson())
+	assert.Equal(t, "amer", restored.Region())
+	assert.Equal(t, project.regionPositions(), restored.regionPositions())
+}
+
+func TestConfigValidateRegions(t *testing.T) {
+	testCases := []struct {
+		modify func(config *Config)
+		err    error
+	}{
+		{func(config *Config) { config.Period = string(EveryDay) }, cfg.ErrInvalidValue},
+		{func(config *Config) { config.Regions[0].Window = "09:00" }, cfg.ErrInvalidValue},
+		{func(config *Config) { config.Regions[0].Window = "09:00-25:00" }, cfg.ErrInvalidValue},
+		{func(config *Config) { config.Regions[0].Country = "xx" }, cfg.ErrNotSupported},
+		{func(config *Config) { config.Regions[1].Name = "emea" }, cfg.ErrInvalidValue},
+		{func(config *Config) { config.Regions[1].Applicants = nil }, cfg.ErrMustNotBeEmpty},
+		{func(config *Config) { config.Regions[1].Applicants[0] = "emea1" }, cfg.ErrInvalidValue},
+		{func(config *Config) { config.Regions[1].Applicants[0] = "unknown" }, cfg.ErrInvalidValue},
+	}
+
+	for i, testCase := range testCases {
+		config := newTestRegionsConfig()
+		testCase.modify(&config)
+
+		assert.ErrorIs(t, config.Validate(), testCase.err, "test case %d", i)
+	}
+
+	config := newTestRegionsConfig()
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, []string{"us"}, config.Countries())
+}
+
+func TestParseWindow(t *testing.T) {
+	hour, minute, length, err := parseWindow("22:30-06:00")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 22, hour)
+	assert.Equal(t, 30, minute)
+	assert.Equal(t, 7*time.Hour+30*time.Minute, length)
+
+	_, _, length, err = parseWindow("09:00 - 09:00")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, length)
+}
@@ -0,0 +1,363 @@
+package dutyscheduler
+
+import (
+	"fmt"
+	"log"
+	"text/template"
+	"time"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+)
+
+// ReminderType defines when a reminder is sent.
+type ReminderType string
+
+const (
+	// UpcomingReminder is sent to the next person of duty some time before their shift
+	UpcomingReminder ReminderType = "upcoming"
+	// DailyReminder is sent to the current person of duty every working day of
+	// their shift but the first and the last ones
+	DailyReminder ReminderType = "daily"
+)
+
+const (
+	reminderTypeParamName    = "type"
+	reminderBeforeParamName  = "before"
+	reminderAtParamName      = "at"
+	reminderMessageParamName = "message"
+)
+
+const (
+	defaultUpcomingReminderMessage = `{{mention .Current}} is on duty from ` +
+		`{{.ShiftStart.Format "Mon 02 Jan 15:04"}}`
+	defaultDailyReminderMessage = `{{mention .Current}} is still on duty` +
+		`{{if not .ShiftEnd.IsZero}} till {{.ShiftEnd.Format "Mon 02 Jan 15:04"}}{{end}}`
+
+	// how long reminders routine sleeps if no reminders are due
+	maxReminderSleep = time.Hour
+)
+
+// ReminderConfig configures a reminder of a project. Upcoming reminders are sent
+// the given time before the next change, daily ones are sent at the given time of day.
+// Message is a template executed with MessageData describing the shift reminded of.
+type ReminderConfig struct {
+	prefix string
+
+	Type    ReminderType
+	Before  time.Duration
+	At      string // HH:MM with optional time zone
+	Message string
+}
+
+func (cfg ReminderConfig) paramWithPrefix() func(name string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
+
+func (cfg *ReminderConfig) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	switch cfg.Type {
+	case UpcomingReminder:
+		if cfg.Before <= 0 {
+			return fmt.Errorf(
+				"%s: must be positive: %w",
+				paramNameFactory(reminderBeforeParamName), cfgUtil.ErrInvalidValue,
+			)
+		}
+
+		if len(cfg.Message) == 0 {
+			cfg.Message = defaultUpcomingReminderMessage
+		}
+	case DailyReminder:
+		if len(cfg.At) == 0 {
+			return fmt.Errorf(
+				"%s: %w", paramNameFactory(reminderAtParamName), cfgUtil.ErrMustNotBeEmpty,
+			)
+		}
+
+		if _, err := dailyTrigger(cfg.At); err != nil {
+			return fmt.Errorf("%s '%s': %w", paramNameFactory(reminderAtParamName), cfg.At, err)
+		}
+
+		if len(cfg.Message) == 0 {
+			cfg.Message = defaultDailyReminderMessage
+		}
+	default:
+		return fmt.Errorf(
+			"%s '%s': %w", paramNameFactory(reminderTypeParamName), cfg.Type, cfgUtil.ErrNotSupported,
+		)
+	}
+
+	if _, err := newMessageTemplate(cfg.Message); err != nil {
+		return fmt.Errorf("%s: %w", paramNameFactory(reminderMessageParamName), err)
+	}
+
+	return nil
+}
+
+func (cfg ReminderConfig) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %s", paramNameFactory(reminderTypeParamName), cfg.Type)
+
+	if cfg.Type == UpcomingReminder {
+		log.Printf("%s: %s", paramNameFactory(reminderBeforeParamName), cfg.Before)
+	} else {
+		log.Printf("%s: %s", paramNameFactory(reminderAtParamName), cfg.At)
+	}
+
+	log.Printf("%s: %s", paramNameFactory(reminderMessageParamName), cfg.Message)
+}
+
+func (cfg *ReminderConfig) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
+
+func dailyTrigger(at string) (*Trigger, error) {
+	return PeriodType(everyPrefix + "day" + atSeparator + at).Trigger()
+}
+
+// reminder is a reminder of a project along with the state of its scheduling
+type reminder struct {
+	cfg ReminderConfig
+
+	message *template.Template
+	trigger *Trigger // for daily reminders
+}
+
+// key identifies the reminder in the state of the project
+func (r *reminder) key() string {
+	if r.cfg.Type == DailyReminder {
+		return string(DailyReminder) + atSeparator + r.cfg.At
+	}
+
+	return r.cfg.Before.String()
+}
+
+func newReminder(cfg ReminderConfig) (*reminder, error) {
+	message, err := newMessageTemplate(cfg.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reminder{
+		cfg:     cfg,
+		message: message,
+	}
+
+	if cfg.Type == DailyReminder {
+		if r.trigger, err = dailyTrigger(cfg.At); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (sch *DutyScheduler) initReminders() error {
+	for i, config := range sch.cfg.Reminders {
+		r, err := newReminder(config)
+		if err != nil {
+			return fmt.Errorf("invalid reminder %d: %w", i, err)
+		}
+
+		sch.reminders = append(sch.reminders, r)
+	}
+
+	return nil
+}
+
+func (sch *DutyScheduler) startRemindersRoutine() {
+	if len(sch.reminders) == 0 {
+		return
+	}
+
+	sch.remindersFinished.Add(1)
+
+	go sch.remindersRoutine()
+}
+
+// remindersRoutine sends reminders when they are due. It is woken up
+// on every change of the schedule.
+func (sch *DutyScheduler) remindersRoutine() {
+	defer sch.remindersFinished.Done()
+
+	for {
+		sleep := maxReminderSleep
+
+		if next := sch.remind(time.Now()); !next.IsZero() && time.Until(next) < sleep {
+			sleep = time.Until(next)
+		}
+
+		timer := time.NewTimer(sleep)
+
+		select {
+		case <-timer.C:
+		case <-sch.remindersQ:
+			timer.Stop()
+		case <-sch.shutdownInit:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// wakeReminders makes reminders routine recalculate the time of the next reminder.
+func (sch *DutyScheduler) wakeReminders() {
+	select {
+	case sch.remindersQ <- struct{}{}:
+	default:
+	}
+}
+
+// remind sends the reminders that are due and returns the time of the next one,
+// zero time means it is unknown till the next change.
+func (sch *DutyScheduler) remind(now time.Time) time.Time {
+	var next time.Time
+
+	for _, r := range sch.reminders {
+		var due time.Time
+
+		if r.cfg.Type == UpcomingReminder {
+			due = sch.remindOfUpcomingShift(r, now)
+		} else {
+			due = sch.remindDaily(r, now)
+		}
+
+		if !due.IsZero() && (next.IsZero() || due.Before(next)) {
+			next = due
+		}
+	}
+
+	return next
+}
+
+func (sch *DutyScheduler) remindOfUpcomingShift(r *reminder, now time.Time) time.Time {
+	schedule := sch.project.schedule(now, 2) // nolint: gomnd
+	if len(schedule) == 0 || len(schedule[0].Person) == 0 {
+		return time.Time{}
+	}
+
+	upcoming := schedule[0]
+
+	// overdue changes happen right away, there is no use in reminding of them
+	if !upcoming.Start.After(now) || upcoming.Start.Equal(sch.project.reminded(r.key())) {
+		return time.Time{}
+	}
+
+	if due := upcoming.Start.Add(-r.cfg.Before); now.Before(due) {
+		return due
+	}
+
+	// the reminder is not sent again after a restart
+	sch.project.setReminded(r.key(), upcoming.Start)
+	sch.dumpState()
+
+	data := MessageData{
+		Project:            sch.ProjectName(),
+		Current:            upcoming.Person,
+		Previous:           sch.previousPerson(),
+		ShiftStart:         upcoming.Start,
+		SkippedForVacation: upcoming.SkippedForVacation,
+		Roles:              upcoming.Roles,
+	}
+
+	if len(schedule) > 1 {
+		data.Next = schedule[1].Person
+		data.ShiftEnd = schedule[1].Start
+	}
+
+	data.Backup = sch.project.backup(data.Roles, data.Next)
+
+	sch.sendReminder(r, data)
+
+	return time.Time{}
+}
+
+// reminded returns what the reminder with the given key has been handled for last:
+// the start of the shift for upcoming reminders, the time of the check for daily ones
+func (p *Project) reminded(key string) time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.remindedOf[key]
+}
+
+func (p *Project) setReminded(key string, t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.remindedOf[key] = t
+}
+
+func (sch *DutyScheduler) remindDaily(r *reminder, now time.Time) time.Time {
+	checked := sch.project.reminded(r.key())
+	if checked.IsZero() {
+		// the reminder has not been checked yet, it is due since the shift started
+		checked = sch.project.LastChange()
+	}
+
+	if checked.IsZero() {
+		// nobody is on duty yet
+		return r.trigger.Next(now)
+	}
+
+	due := r.trigger.Next(checked)
+	if now.Before(due) {
+		return due
+	}
+
+	// the reminder is not sent again after a restart
+	sch.project.setReminded(r.key(), now)
+	sch.dumpState()
+
+	shiftStart := sch.project.LastChange()
+	schedule := sch.project.schedule(now, 1)
+
+	switch {
+	case !sameDay(now, due):
+		sch.logger.Infof("not sending daily reminder due at %s since it is overdue", due)
+	case sameDay(shiftStart, due):
+		// the person has just been notified about the change
+	case len(schedule) != 0 && sameDay(schedule[0].Start, due):
+		// the shift ends today, the person is notified about the change
+	case sch.project.isDayOff(due):
+		sch.logger.Infof("not sending daily reminder since it is a day off")
+	default:
+		data := MessageData{
+			Project:    sch.ProjectName(),
+			Current:    sch.project.CurrentPerson(),
+			ShiftStart: shiftStart,
+			Roles:      sch.project.Roles(),
+		}
+
+		if len(schedule) != 0 {
+			data.Next = schedule[0].Person
+			data.ShiftEnd = schedule[0].Start
+		}
+
+		data.Backup = sch.project.backup(data.Roles, data.Next)
+
+		sch.sendReminder(r, data)
+	}
+
+	return r.trigger.Next(now)
+}
+
+func (sch *DutyScheduler) sendReminder(r *reminder, data MessageData) {
+	sch.logger.Infof("sending %s reminder to %s", r.cfg.Type, data.Current)
+
+	sch.eventsQ <- Event{
+		nextChange: sch.nextChange(),
+		message:    r.message,
+		data:       data,
+	}
+}
+
+// sameDay reports whether both times are within the same day in the time zone of t2
+func sameDay(t1, t2 time.Time) bool {
+	y1, m1, d1 := t1.In(t2.Location()).Date()
+	y2, m2, d2 := t2.Date()
+
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
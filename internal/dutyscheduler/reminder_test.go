@@ -0,0 +1,217 @@
+package dutyscheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+func newTestReminderScheduler(t *testing.T, reminders ...ReminderConfig) *DutyScheduler {
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Period:     string(EveryDay),
+		Reminders:  reminders,
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	sch, err := newDutySchedulerStopped(config, statedumper.NewDummyDumper(), nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	return sch
+}
+
+// popEvent returns the text of the queued event or an empty string if there is none
+func popEvent(sch *DutyScheduler) string {
+	select {
+	case e := <-sch.eventsQ:
+		return sch.eventText(e, nil)
+	default:
+		return ""
+	}
+}
+
+func TestUpcomingReminder(t *testing.T) {
+	sch := newTestReminderScheduler(t, ReminderConfig{
+		Type:    UpcomingReminder,
+		Before:  2 * time.Hour,
+		Message: `{{.Current}} takes over from {{.Previous}} at {{.ShiftStart.Format "15:04"}}`,
+	})
+
+	now := time.Now().Truncate(time.Minute)
+
+	sch.project.SetTimeOfLastChange(now.Add(-21 * time.Hour))
+	sch.project.NextPerson()
+
+	// 3 hours before the change
+	assert.Equal(t, now.Add(time.Hour), sch.remind(now))
+	assert.Empty(t, popEvent(sch))
+
+	now = now.Add(90 * time.Minute)
+
+	assert.True(t, sch.remind(now).IsZero())
+	assert.Equal(t,
+		"test2 takes over from test1 at "+now.Add(90*time.Minute).Format("15:04"), popEvent(sch),
+	)
+
+	// the shift has already been reminded of
+	assert.True(t, sch.remind(now.Add(time.Minute)).IsZero())
+	assert.Empty(t, popEvent(sch))
+
+	// even after a restart
+	state := dumpTestState(t, sch.project)
+
+	sch = newTestReminderScheduler(t, sch.cfg.Reminders...)
+	restoreTestState(t, sch.project, state)
+
+	assert.True(t, sch.remind(now.Add(time.Minute)).IsZero())
+	assert.Empty(t, popEvent(sch))
+}
+
+func TestDailyReminder(t *testing.T) {
+	sch := newTestReminderScheduler(t, ReminderConfig{
+		Type:    DailyReminder,
+		At:      "10:00 UTC",
+		Message: "{{.Current}} is on duty, {{.Next}} is next",
+	})
+
+	r := sch.reminders[0]
+
+	testCases := []struct {
+		name       string
+		period     PeriodType
+		lastChange time.Time
+		now        time.Time
+		expected   string
+	}{
+		{
+			name:       "second day of the shift",
+			period:     EveryWeek,
+			lastChange: time.Date(2022, time.March, 1, 9, 0, 0, 0, time.UTC),
+			now:        time.Date(2022, time.March, 2, 10, 0, 30, 0, time.UTC),
+			expected:   "test1 is on duty, test2 is next",
+		},
+		{
+			name:       "first day of the shift",
+			period:     EveryWeek,
+			lastChange: time.Date(2022, time.March, 2, 9, 0, 0, 0, time.UTC),
+			now:        time.Date(2022, time.March, 2, 10, 0, 30, 0, time.UTC),
+		},
+		{
+			name:       "last day of the shift",
+			period:     EveryWeek,
+			lastChange: time.Date(2022, time.February, 23, 11, 0, 0, 0, time.UTC),
+			now:        time.Date(2022, time.March, 2, 10, 0, 30, 0, time.UTC),
+		},
+		{
+			name:       "daily rotation",
+			period:     "every day at 11:00 UTC",
+			lastChange: time.Date(2022, time.March, 1, 11, 0, 0, 0, time.UTC),
+			now:        time.Date(2022, time.March, 2, 10, 0, 30, 0, time.UTC),
+		},
+		{
+			name:       "weekend",
+			period:     EveryWeek,
+			lastChange: time.Date(2022, time.March, 2, 9, 0, 0, 0, time.UTC),
+			now:        time.Date(2022, time.March, 5, 10, 0, 30, 0, time.UTC),
+		},
+	}
+
+	for _, testCase := range testCases {
+		sch.cfg.Period = string(testCase.period)
+
+		sch.project = nil
+		if err := sch.initProject(sch.cfg, nil); err != nil {
+			t.Fatalf("could not init project: %v", err)
+		}
+
+		sch.project.SetTimeOfLastChange(testCase.lastChange)
+		sch.project.NextPerson()
+		sch.project.setReminded(r.key(), testCase.now.Add(-time.Hour))
+
+		next := sch.remind(testCase.now)
+
+		assert.Equal(t, testCase.expected, popEvent(sch), testCase.name)
+		assert.Equal(t, testCase.now.Add(24*time.Hour).Truncate(time.Hour), next, testCase.name)
+
+		// nothing is sent twice
+		sch.remind(testCase.now)
+		assert.Empty(t, popEvent(sch), testCase.name)
+	}
+}
+
+func TestDailyReminderRestart(t *testing.T) {
+	reminder := ReminderConfig{
+		Type:    DailyReminder,
+		At:      "10:00 UTC",
+		Message: "{{.Current}} is on duty",
+	}
+
+	// restarts the scheduler of a weekly project with the given state
+	restart := func(state string) *DutyScheduler {
+		sch := newTestReminderScheduler(t, reminder)
+		sch.cfg.Period = string(EveryWeek)
+
+		sch.project = nil
+		if err := sch.initProject(sch.cfg, nil); err != nil {
+			t.Fatalf("could not init project: %v", err)
+		}
+
+		if len(state) != 0 {
+			restoreTestState(t, sch.project, state)
+		}
+
+		return sch
+	}
+
+	sch := restart("")
+
+	now := time.Date(2022, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	sch.project.SetTimeOfLastChange(now.Add(-24 * time.Hour))
+	sch.project.NextPerson()
+
+	assert.Equal(t, now.Add(time.Hour), sch.remind(now))
+	assert.Empty(t, popEvent(sch))
+
+	// the bot restarts just after the reminder is due
+	sch = restart(dumpTestState(t, sch.project))
+
+	now = now.Add(time.Hour + time.Minute)
+
+	sch.remind(now)
+	assert.Equal(t, "test1 is on duty", popEvent(sch))
+
+	// and once again after it has been sent
+	sch = restart(dumpTestState(t, sch.project))
+
+	sch.remind(now.Add(time.Minute))
+	assert.Empty(t, popEvent(sch))
+}
+
+func TestReminderConfigValidate(t *testing.T) {
+	invalid := []ReminderConfig{
+		{Type: "hourly"},
+		{Type: UpcomingReminder},
+		{Type: UpcomingReminder, Before: time.Hour, Message: "{{.Unknown}}"},
+		{Type: DailyReminder},
+		{Type: DailyReminder, At: "25:00"},
+		{Type: DailyReminder, At: "10:00 Mars/Olympus"},
+	}
+
+	for _, config := range invalid {
+		assert.Error(t, config.Validate(), "%+v", config)
+	}
+
+	config := ReminderConfig{Type: DailyReminder, At: "10:00"}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, defaultDailyReminderMessage, config.Message)
+}
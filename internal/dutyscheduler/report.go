@@ -0,0 +1,182 @@
+package dutyscheduler
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+// DayKind is the kind of a calendar day in reports
+type DayKind string
+
+const (
+	WorkingDay DayKind = "working_day"
+	Weekend    DayKind = "weekend"
+	Holiday    DayKind = "holiday" // a public holiday falling on a weekday
+)
+
+// DutyReport is the amount of duty a person has done in a range of dates
+type DutyReport struct {
+	Project     string  `json:"project"`
+	Person      string  `json:"person"`
+	WorkingDays float64 `json:"working_days"` // time on duty at working days in days
+	Weekends    float64 `json:"weekends"`     // time on duty at weekends in days
+	Holidays    float64 `json:"holidays"`     // time on duty at public holidays in days
+	Total       float64 `json:"total"`        // time on duty in days
+}
+
+// Report returns the duty every person has done in [from, till) according to the
+// given history of the project. The shift going on at from is taken from the last
+// record made before it, the last shift lasts till now. Days are split by the
+// calendar of the region of the shift in its time zone or by the default calendar
+// in the time zone of the person. Active applicants go first in the order of the
+// rotation, the others that have been on duty follow in alphabetical order.
+func (p *Project) Report(
+	history []statedumper.HistoryRecord, from, till, now time.Time,
+) ([]DutyReport, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var shifts []statedumper.HistoryRecord
+
+	for _, r := range history {
+		if r.TakesDuty() {
+			shifts = append(shifts, r)
+		}
+	}
+
+	sort.SliceStable(shifts, func(i, j int) bool {
+		return shifts[i].Time.Before(shifts[j].Time)
+	})
+
+	totals := make(map[string]map[DayKind]time.Duration)
+
+	for i, r := range shifts {
+		start, end := r.Time, now
+		if i+1 < len(shifts) {
+			end = shifts[i+1].Time
+		}
+
+		if start.Before(from) {
+			start = from
+		}
+
+		if end.After(till) {
+			end = till
+		}
+
+		if !start.Before(end) {
+			continue
+		}
+
+		if totals[r.Person] == nil {
+			totals[r.Person] = make(map[DayKind]time.Duration)
+		}
+
+		if err := p.addReportDuty(totals[r.Person], r, start, end); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.dutyReports(totals), nil
+}
+
+// addReportDuty adds the time between start and end of the shift of the record
+// to the totals split by the kinds of days
+func (p *Project) addReportDuty(
+	totals map[DayKind]time.Duration, r statedumper.HistoryRecord, start, end time.Time,
+) error {
+	region := p.findRegion(r.Region)
+
+	loc := p.applicants[r.Person].location()
+	if region != nil {
+		loc = region.loc
+	}
+
+	for start = start.In(loc); start.Before(end); {
+		year, month, date := start.Date()
+
+		next := time.Date(year, month, date+1, 0, 0, 0, 0, loc)
+		if next.After(end) {
+			next = end
+		}
+
+		kind, err := p.dayKind(region, start)
+		if err != nil {
+			return fmt.Errorf("could not check if %s is a day off: %w", start.Format(DateLayout), err)
+		}
+
+		totals[kind] += next.Sub(start)
+		start = next
+	}
+
+	return nil
+}
+
+// dayKind returns the kind of the day of t according to the calendar of the
+// region if any or to the default one. Without the production calendar
+// only weekends are known.
+func (p *Project) dayKind(r *region, t time.Time) (DayKind, error) {
+	var (
+		isDayOff bool
+		err      error
+	)
+
+	if r != nil {
+		isDayOff, err = p.checkDayOffIn(r, t)
+	} else {
+		isDayOff, err = p.checkDayOff(t)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case !isDayOff:
+		return WorkingDay, nil
+	case isWeekEndDay(t):
+		return Weekend, nil
+	}
+
+	return Holiday, nil
+}
+
+func (p *Project) dutyReports(totals map[string]map[DayKind]time.Duration) []DutyReport {
+	names := append([]string(nil), p.dutyApplicants...)
+
+	var others []string
+
+	for name := range totals {
+		if indexOf(p.dutyApplicants, name) < 0 {
+			others = append(others, name)
+		}
+	}
+
+	sort.Strings(others)
+
+	reports := make([]DutyReport, 0, len(names)+len(others))
+
+	for _, name := range append(names, others...) {
+		total := totals[name]
+
+		reports = append(reports, DutyReport{
+			Project:     p.cfg.Name,
+			Person:      name,
+			WorkingDays: reportDays(total[WorkingDay]),
+			Weekends:    reportDays(total[Weekend]),
+			Holidays:    reportDays(total[Holiday]),
+			Total:       reportDays(total[WorkingDay] + total[Weekend] + total[Holiday]),
+		})
+	}
+
+	return reports
+}
+
+// reportDays converts the duration to days rounded to hundredths
+func reportDays(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(day)*100) / 100 //nolint: gomnd
+}
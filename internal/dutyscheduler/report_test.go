@@ -0,0 +1,110 @@
+package dutyscheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+// holidaysDBMock has weekends and the given dates as day offs
+type holidaysDBMock []time.Time
+
+func (db holidaysDBMock) IsDayOff(t time.Time) (bool, error) {
+	for _, holiday := range db {
+		if sameDay(t, holiday) {
+			return true, nil
+		}
+	}
+
+	return isWeekEndDay(t), nil
+}
+
+func newTestReportHistory() []statedumper.HistoryRecord {
+	at := func(d, h int) time.Time {
+		return time.Date(2024, time.January, d, h, 0, 0, 0, time.Local)
+	}
+
+	return []statedumper.HistoryRecord{
+		{Time: at(4, 10), Person: "test1", Reason: statedumper.ReasonScheduled},
+		{Time: at(5, 10), Person: "test1", Reason: statedumper.ReasonSkippedForVacation},
+		{Time: at(5, 10), Person: "test2", Reason: statedumper.ReasonScheduled},
+		{Time: at(8, 10), Person: "test1", Reason: statedumper.ReasonForced},
+		{Time: at(8, 14), Person: "test3", Reason: statedumper.ReasonSwap},
+	}
+}
+
+func TestProjectReport(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name:       "test_project",
+		Applicants: Applicants{{Name: "test1"}, {Name: "test2"}},
+		Period:     string(EveryDay),
+	})
+
+	// Friday is a public holiday
+	friday := time.Date(2024, time.January, 5, 0, 0, 0, 0, time.Local)
+	project.SetDayOffsDB(holidaysDBMock{friday})
+
+	from, till, now := friday, friday.AddDate(0, 0, 4), friday.AddDate(0, 0, 5)
+
+	reports, err := project.Report(newTestReportHistory(), from, till, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []DutyReport{
+		{
+			Project: "test_project", Person: "test1",
+			WorkingDays: 0.17, Holidays: 0.42, Total: 0.58,
+		},
+		{
+			Project: "test_project", Person: "test2",
+			WorkingDays: 0.42, Weekends: 2, Holidays: 0.58, Total: 3,
+		},
+		{
+			Project: "test_project", Person: "test3",
+			WorkingDays: 0.42, Total: 0.42,
+		},
+	}, reports)
+
+	// the last shift lasts till now
+	now = time.Date(2024, time.January, 8, 16, 0, 0, 0, time.Local)
+
+	reports, err = project.Report(newTestReportHistory(), from, till, now)
+	assert.NoError(t, err)
+	assert.Len(t, reports, 3)
+	assert.Equal(t, 0.08, reports[2].Total)
+
+	// without the production calendar only weekends are known
+	project.SetDayOffsDB(nil)
+
+	now = till
+
+	reports, err = project.Report(newTestReportHistory(), from, till, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.58, reports[0].WorkingDays)
+	assert.Equal(t, 1.0, reports[1].WorkingDays)
+	assert.Equal(t, 2.0, reports[1].Weekends)
+
+	// days missing in the calendar are not guessed
+	project.SetDayOffsDB(dummyDayOffDB{})
+
+	_, err = project.Report(newTestReportHistory(), from, till, now)
+	assert.Error(t, err)
+}
+
+func TestProjectReportEmpty(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name:       "test_project",
+		Applicants: Applicants{{Name: "test1"}, {Name: "test2"}},
+		Period:     string(EveryDay),
+	})
+
+	monday := time.Date(2024, time.January, 8, 0, 0, 0, 0, time.Local)
+
+	reports, err := project.Report(nil, monday, monday.AddDate(0, 0, 7), monday.AddDate(0, 0, 7))
+	assert.NoError(t, err)
+	assert.Equal(t, []DutyReport{
+		{Project: "test_project", Person: "test1"},
+		{Project: "test_project", Person: "test2"},
+	}, reports)
+}
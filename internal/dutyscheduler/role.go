@@ -0,0 +1,323 @@
+package dutyscheduler
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+)
+
+const (
+	roleNameParamName       = "name"
+	roleOffsetParamName     = "offset"
+	roleApplicantsParamName = "applicants"
+)
+
+const (
+	// PrimaryRole is the role of the person of duty
+	PrimaryRole = "primary"
+
+	defaultRoleOffset = 1
+)
+
+// RoleConfig configures a role taken besides the primary one, e.g. a backup.
+// A role either rotates through the applicants of the project following the
+// primary person at the given offset or rotates through its own applicants.
+// Nobody holds two roles at once.
+type RoleConfig struct {
+	prefix string
+
+	Name string
+
+	Offset     int        // position in the rotation relative to the primary person
+	Applicants Applicants // own applicants of the role
+}
+
+func (cfg RoleConfig) paramWithPrefix() func(name string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
+
+func (cfg *RoleConfig) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	if len(cfg.Name) == 0 {
+		return fmt.Errorf("%s: %w", paramNameFactory(roleNameParamName), cfgUtil.ErrMustNotBeEmpty)
+	}
+
+	if cfg.Name == PrimaryRole {
+		return fmt.Errorf(
+			"%s '%s': the role is reserved: %w",
+			paramNameFactory(roleNameParamName), cfg.Name, cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	if len(cfg.Applicants) == 0 {
+		if cfg.Offset == 0 {
+			cfg.Offset = defaultRoleOffset
+		}
+
+		if cfg.Offset < 0 {
+			return fmt.Errorf(
+				"%s: must be positive: %w",
+				paramNameFactory(roleOffsetParamName), cfgUtil.ErrInvalidValue,
+			)
+		}
+
+		return nil
+	}
+
+	if cfg.Offset != 0 {
+		return fmt.Errorf(
+			"%s: only used with the applicants of the project: %w",
+			paramNameFactory(roleOffsetParamName), cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	for i := range cfg.Applicants {
+		cfg.Applicants[i].SetPrefix(
+			fmt.Sprintf("%s[%d]", paramNameFactory(roleApplicantsParamName), i),
+		)
+
+		if err := cfg.Applicants[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cfg RoleConfig) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %s", paramNameFactory(roleNameParamName), cfg.Name)
+
+	if len(cfg.Applicants) == 0 {
+		log.Printf("%s: %d", paramNameFactory(roleOffsetParamName), cfg.Offset)
+		return
+	}
+
+	log.Printf("%s: %s", paramNameFactory(roleApplicantsParamName), cfg.Applicants)
+}
+
+func (cfg *RoleConfig) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
+
+// validateRoles validates the roles of the project, their names must be unique
+func (cfg *Config) validateRoles() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	names := make(map[string]bool, len(cfg.Roles))
+
+	for i := range cfg.Roles {
+		cfg.Roles[i].SetPrefix(fmt.Sprintf("%s[%d]", paramNameFactory(rolesParamName), i))
+
+		if err := cfg.Roles[i].Validate(); err != nil {
+			return err
+		}
+
+		if names[cfg.Roles[i].Name] {
+			return fmt.Errorf(
+				"%s: role '%s' is listed twice: %w",
+				paramNameFactory(rolesParamName), cfg.Roles[i].Name, cfgUtil.ErrInvalidValue,
+			)
+		}
+
+		names[cfg.Roles[i].Name] = true
+	}
+
+	return nil
+}
+
+// role is a role of the project along with the person holding it
+type role struct {
+	name       string
+	offset     int
+	applicants []string // nil if the role shares the applicants of the project
+
+	holder string
+}
+
+func newRole(cfg RoleConfig) *role {
+	r := &role{
+		name:   cfg.Name,
+		offset: cfg.Offset,
+	}
+
+	for _, applicant := range cfg.Applicants {
+		if applicant.IsActive() {
+			r.applicants = append(r.applicants, applicant.Name)
+		}
+	}
+
+	return r
+}
+
+// candidates returns the applicants that may hold the role, by default the ones
+// of the given rotation
+func (r *role) candidates(rotation []string) []string {
+	if r.applicants == nil {
+		return rotation
+	}
+
+	return r.applicants
+}
+
+// pickRoleHolders picks the holders of the roles for the shift starting at t given
+// the primary person, the rotation they belong to, their position in it and the
+// previous holders.
+// Persons on vacation and the ones already holding a role are passed over, a role
+// is left empty if nobody is left to take it. If keep is set, the previous holders
+// keep their roles unless they have become the primary person or are not among
+// the candidates anymore.
+func (p *Project) pickRoleHolders(
+	primary string, rotation []string, position uint64, prevHolders map[string]string,
+	keep bool, t time.Time,
+) map[string]string {
+	holders := make(map[string]string, len(p.roles))
+	taken := map[string]bool{primary: true}
+
+	if keep {
+		for _, r := range p.roles {
+			prev := prevHolders[r.name]
+			if len(prev) != 0 && !taken[prev] && indexOf(r.candidates(rotation), prev) >= 0 {
+				holders[r.name] = prev
+				taken[prev] = true
+			}
+		}
+	}
+
+	for _, r := range p.roles {
+		if _, ok := holders[r.name]; ok {
+			continue
+		}
+
+		candidates := r.candidates(rotation)
+		start := uint64(0)
+
+		if r.applicants == nil {
+			start = position + uint64(r.offset)
+		} else if prev := indexOf(candidates, prevHolders[r.name]); prev >= 0 {
+			start = uint64(prev) + 1
+		}
+
+		for i := 0; i < len(candidates); i++ {
+			candidate := candidates[(start+uint64(i))%uint64(len(candidates))]
+
+			if taken[candidate] {
+				continue
+			}
+
+			if p.shouldConsiderVacations() && p.isOnVacation(candidate, t) {
+				continue
+			}
+
+			holders[r.name] = candidate
+			taken[candidate] = true
+
+			break
+		}
+	}
+
+	return holders
+}
+
+// assignRoles assigns the roles for the shift of the current person
+func (p *Project) assignRoles(primary string, t time.Time) {
+	applicants, position := p.rotation(p.region)
+
+	p.setRoleHolders(p.pickRoleHolders(primary, applicants, *position, p.roleHolders(), false, t))
+}
+
+func (p *Project) setRoleHolders(holders map[string]string) {
+	for _, r := range p.roles {
+		if r.holder == holders[r.name] {
+			continue
+		}
+
+		r.holder = holders[r.name]
+
+		if len(r.holder) == 0 {
+			p.logger.Warnf("nobody is left to take role %s", r.name)
+			continue
+		}
+
+		p.logger.Infof("%s takes role %s", r.holder, r.name)
+	}
+}
+
+// Roles returns the persons holding the roles besides the primary one
+func (p *Project) Roles() map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.roleHolders()
+}
+
+func (p *Project) roleHolders() map[string]string {
+	holders := make(map[string]string, len(p.roles))
+
+	for _, r := range p.roles {
+		if len(r.holder) != 0 {
+			holders[r.name] = r.holder
+		}
+	}
+
+	return holders
+}
+
+// backup returns who covers the primary person given the holders of the roles:
+// the holder of the first role if there are any roles, the next person otherwise.
+func (p *Project) backup(holders map[string]string, next string) string {
+	if len(p.roles) == 0 {
+		return next
+	}
+
+	return holders[p.roles[0].name]
+}
+
+// AssignMissingRoles assigns the roles that nobody holds to the persons available
+// now, e.g. when a role has been added to the config.
+func (p *Project) AssignMissingRoles() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.timeOfLastChange.IsZero() {
+		return
+	}
+
+	applicants, position := p.rotation(p.region)
+
+	p.setRoleHolders(p.pickRoleHolders(
+		p.currentPersonLocked(), applicants, *position, p.roleHolders(), true, time.Now(),
+	))
+}
+
+// formatRoles returns holders of the roles in a human readable form
+func formatRoles(holders map[string]string) string {
+	roles := make([]string, 0, len(holders))
+	for role := range holders {
+		roles = append(roles, role)
+	}
+
+	sort.Strings(roles)
+
+	for i, role := range roles {
+		roles[i] = role + ": " + holders[role]
+	}
+
+	return strings.Join(roles, ", ")
+}
+
+func indexOf(list []string, s string) int {
+	for i, elem := range list {
+		if elem == s {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,242 @@
+package dutyscheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+func TestProjectRolesWithOffset(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3,test4"),
+		Roles:      []RoleConfig{{Name: "backup"}},
+		Period:     string(EveryDay),
+	})
+
+	assert.Equal(t, "test1", project.NextPerson())
+	assert.Equal(t, map[string]string{"backup": "test2"}, project.Roles())
+
+	assert.Equal(t, "test2", project.NextPerson())
+	assert.Equal(t, map[string]string{"backup": "test3"}, project.Roles())
+
+	// the backup on vacation is passed over
+	project.SetVacationDB(dummyVacationDB{true, "test4"})
+
+	assert.Equal(t, "test3", project.NextPerson())
+	assert.Equal(t, map[string]string{"backup": "test1"}, project.Roles())
+}
+
+func TestProjectRolesWithOwnApplicants(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,lead1"),
+		Roles: []RoleConfig{
+			{Name: "second", Offset: 1},
+			{Name: "lead", Applicants: ParseApplicants("lead1,lead2")},
+		},
+		Period: string(EveryDay),
+	})
+
+	expected := []struct {
+		primary string
+		roles   map[string]string
+	}{
+		{"test1", map[string]string{"second": "test2", "lead": "lead1"}},
+		{"test2", map[string]string{"second": "lead1", "lead": "lead2"}},
+		// lead1 is on duty, so they can not be a lead this time
+		{"lead1", map[string]string{"second": "test1", "lead": "lead2"}},
+		{"test1", map[string]string{"second": "test2", "lead": "lead1"}},
+	}
+
+	for i, step := range expected {
+		assert.Equal(t, step.primary, project.NextPerson(), "step %d", i)
+		assert.Equal(t, step.roles, project.Roles(), "step %d", i)
+	}
+
+	assert.Equal(t, "lead2", project.Person("lead2").String())
+}
+
+func TestProjectRolesHandOff(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Roles:      []RoleConfig{{Name: "backup"}},
+		Period:     string(EveryDay),
+	})
+
+	project.SetTimeOfLastChange(time.Now())
+	project.NextPerson()
+
+	prevPerson, _, err := project.HandOff("test2")
+	assert.NoError(t, err)
+	assert.Equal(t, "test1", prevPerson)
+
+	// nobody holds two roles
+	assert.Equal(t, "test2", project.CurrentPerson())
+	assert.Equal(t, map[string]string{"backup": "test1"}, project.Roles())
+}
+
+func TestProjectRolesSchedule(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Roles:      []RoleConfig{{Name: "backup"}},
+		Period:     string(EveryDay),
+	})
+
+	project.SetTimeOfLastChange(time.Now())
+	project.NextPerson()
+
+	schedule := project.Schedule(3)
+
+	if assert.Len(t, schedule, 3) {
+		assert.Equal(t, map[string]string{"backup": "test3"}, schedule[0].Roles)
+		assert.Equal(t, map[string]string{"backup": "test1"}, schedule[1].Roles)
+		assert.Equal(t, map[string]string{"backup": "test2"}, schedule[2].Roles)
+		assert.True(t, strings.HasSuffix(schedule[0].String(), "test2 (backup: test3)"))
+	}
+
+	// simulation does not change the state
+	assert.Equal(t, map[string]string{"backup": "test2"}, project.Roles())
+}
+
+func TestProjectRolesRestoreState(t *testing.T) {
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Roles:      []RoleConfig{{Name: "backup"}},
+		Period:     string(EveryDay),
+	}
+
+	project := newTestProject(t, config)
+
+	project.SetTimeOfLastChange(time.Unix(1609074301, 0))
+	project.NextPerson()
+	project.NextPerson()
+
+	buf := &strings.Builder{}
+	assert.NoError(t, project.DumpState(buf))
+
+	state, err := statedumper.NewSchedulingState(strings.NewReader(buf.String()))
+	assert.NoError(t, err)
+
+	restored := newTestProject(t, config)
+	assert.NoError(t, restored.RestoreState(state))
+
+	assert.Equal(t, "test2", restored.CurrentPerson())
+	assert.Equal(t, map[string]string{"backup": "test3"}, restored.Roles())
+}
+
+func TestProjectRolesRemovedHolder(t *testing.T) {
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Roles: []RoleConfig{
+			{Name: "backup"},
+			{Name: "lead", Applicants: ParseApplicants("lead1,lead2")},
+		},
+		Period: string(EveryDay),
+	}
+
+	project := newTestProject(t, config)
+
+	project.SetTimeOfLastChange(time.Now())
+	project.NextPerson()
+
+	assert.Equal(t, map[string]string{"backup": "test2", "lead": "lead1"}, project.Roles())
+
+	state := dumpTestState(t, project)
+
+	// test2 has left the rotation and lead1 has become inactive since the restart
+	inactive := false
+
+	restored := newTestProject(t, Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test3"),
+		Roles: []RoleConfig{
+			{Name: "backup"},
+			{Name: "lead", Applicants: Applicants{
+				{Name: "lead1", Active: &inactive}, {Name: "lead2"},
+			}},
+		},
+		Period: string(EveryDay),
+	})
+	restoreTestState(t, restored, state)
+
+	restored.AssignMissingRoles()
+
+	assert.Equal(t, map[string]string{"backup": "test3", "lead": "lead2"}, restored.Roles())
+
+	// holders that are still candidates keep their roles
+	restored = newTestProject(t, config)
+	restoreTestState(t, restored, state)
+
+	restored.AssignMissingRoles()
+
+	assert.Equal(t, map[string]string{"backup": "test2", "lead": "lead1"}, restored.Roles())
+}
+
+func TestDutySchedulerRolesMessage(t *testing.T) {
+	config := Config{
+		Name:           "test_project",
+		Applicants:     ParseApplicants("test1,test2,test3"),
+		Roles:          []RoleConfig{{Name: "backup", Offset: 2}},
+		MessagePattern: `{{.Current}}, backup {{.Backup}}, next {{.Next}}`,
+		Period:         string(EveryDay),
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	sch, err := newDutySchedulerStopped(config, statedumper.NewDummyDumper(), nil)
+	if err != nil {
+		t.Fatalf("could not init dutyscheduler: %v", err)
+	}
+
+	sch.Rotate()
+
+	e := <-sch.eventsQ
+	assert.Equal(t, "test1, backup test3, next test2", sch.eventText(e, nil))
+	assert.Equal(t, map[string]string{"backup": "test3"}, e.roles)
+	assert.Equal(t, "test1 is on duty (backup: test3)", mustHandleCommand(t, sch, dutyCommand))
+}
+
+func mustHandleCommand(t *testing.T, sch *DutyScheduler, cmd string, args ...string) string {
+	reply, err := sch.HandleCommand(cmd, args)
+	if err != nil {
+		t.Fatalf("command %s failed: %v", cmd, err)
+	}
+
+	return reply
+}
+
+func TestConfigValidateRoles(t *testing.T) {
+	invalid := [][]RoleConfig{
+		{{}},
+		{{Name: PrimaryRole}},
+		{{Name: "backup", Offset: -1}},
+		{{Name: "backup", Offset: 1, Applicants: ParseApplicants("lead1")}},
+		{{Name: "backup"}, {Name: "backup"}},
+		{{Name: "backup", Applicants: Applicants{{Name: "lead1", Weight: -1}}}},
+	}
+
+	for _, roles := range invalid {
+		config := Config{Name: "test_project", Applicants: ParseApplicants("test1,test2"), Roles: roles}
+		assert.Error(t, config.Validate(), "%+v", roles)
+	}
+
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2"),
+		Roles:      []RoleConfig{{Name: "backup"}},
+	}
+
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, defaultRoleOffset, config.Roles[0].Offset)
+}
@@ -0,0 +1,156 @@
+package dutyscheduler
+
+import (
+	"math"
+	"strings"
+
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+// rotationDiff is the change of the applicants of a rotation since its state was saved
+type rotationDiff struct {
+	added   []string // applicants that have joined the rotation, in the order of the config
+	removed []string // applicants that have left the rotation, in the saved order
+}
+
+func (d rotationDiff) empty() bool {
+	return len(d.added) == 0 && len(d.removed) == 0
+}
+
+// mergeRotation merges the saved order of a rotation with the applicants configured
+// now. The saved order is kept, the applicants missing in it join the rotation at
+// the end, the ones that are not configured any more are dropped. The position of the
+// current person in the merged rotation is returned. If the current person has been
+// dropped, the position is the one before the person that would have followed them,
+// so that the rotation goes on with that person.
+func mergeRotation(
+	saved []string, current string, configured []string,
+) ([]string, uint64, rotationDiff) {
+	var diff rotationDiff
+
+	rotation := make([]string, 0, len(configured))
+
+	for _, name := range saved {
+		if indexOf(configured, name) >= 0 && indexOf(rotation, name) < 0 {
+			rotation = append(rotation, name)
+		} else {
+			diff.removed = append(diff.removed, name)
+		}
+	}
+
+	for _, name := range configured {
+		if indexOf(rotation, name) < 0 {
+			rotation = append(rotation, name)
+			diff.added = append(diff.added, name)
+		}
+	}
+
+	if len(current) == 0 || len(rotation) == 0 {
+		return rotation, math.MaxUint64, diff
+	}
+
+	if i := indexOf(rotation, current); i >= 0 {
+		return rotation, uint64(i), diff
+	}
+
+	// the current person has left, the first one remaining after them goes next
+	if i := indexOf(saved, current); i >= 0 {
+		for j := 1; j < len(saved); j++ {
+			next := indexOf(rotation, saved[(i+j)%len(saved)])
+			if next >= 0 {
+				return rotation, uint64((next + len(rotation) - 1) % len(rotation)), diff
+			}
+		}
+	}
+
+	return rotation, math.MaxUint64, diff
+}
+
+// keepRound moves the position in the merged rotation to the round of the saved
+// position, so that applicants with weights keep their share of turns after restarts
+func keepRound(position, savedPosition uint64, saved, rotation []string) uint64 {
+	if position == math.MaxUint64 || savedPosition == math.MaxUint64 || len(saved) == 0 {
+		return position
+	}
+
+	return savedPosition/uint64(len(saved))*uint64(len(rotation)) + position
+}
+
+// restoreRotation restores the order of the rotation with the given title from the
+// state merged with the applicants configured now and logs the difference
+func (p *Project) restoreRotation(
+	title string, saved []string, savedPosition uint64, current string, configured []string,
+) ([]string, uint64) {
+	rotation, position, diff := mergeRotation(saved, current, configured)
+	position = keepRound(position, savedPosition, saved, rotation)
+
+	for _, name := range diff.removed {
+		p.logger.Infof("%s: %s has left the rotation", title, name)
+	}
+
+	for _, name := range diff.added {
+		p.logger.Infof("%s: %s has joined the rotation at the end", title, name)
+	}
+
+	if !diff.empty() || strings.Join(rotation, ",") != strings.Join(configured, ",") {
+		p.logger.Infof("%s: the rotation is %s", title, strings.Join(rotation, ", "))
+	}
+
+	return rotation, position
+}
+
+// restoreRotations restores the rotations of the project from the state. States
+// saved without the order of rotations keep the positions as they are.
+func (p *Project) restoreRotations(state statedumper.SchedulingState) {
+	if len(state.Rotation) != 0 {
+		p.dutyApplicants, p.currentPerson = p.restoreRotation(
+			"applicants", state.Rotation, state.CurrentPerson, state.CurrentName, p.dutyApplicants,
+		)
+	} else {
+		p.currentPerson = state.CurrentPerson
+	}
+
+	for _, r := range p.regions {
+		position, ok := state.RegionPositions[r.name]
+		saved := state.RegionRotations[r.name]
+
+		if len(saved) == 0 {
+			if ok {
+				r.position = position
+			}
+
+			continue
+		}
+
+		current := ""
+		if !ok {
+			position = math.MaxUint64
+		} else if position != math.MaxUint64 {
+			current = saved[position%uint64(len(saved))]
+		}
+
+		r.applicants, r.position = p.restoreRotation(
+			"region "+r.name, saved, position, current, r.applicants,
+		)
+	}
+}
+
+// currentName returns the person at the current position of the project rotation,
+// empty if the rotation has not started
+func (p *Project) currentName() string {
+	if p.currentPerson == math.MaxUint64 {
+		return ""
+	}
+
+	return p.dutyApplicants[p.currentPerson%uint64(len(p.dutyApplicants))]
+}
+
+func (p *Project) regionRotations() map[string][]string {
+	rotations := make(map[string][]string, len(p.regions))
+
+	for _, r := range p.regions {
+		rotations[r.name] = r.applicants
+	}
+
+	return rotations
+}
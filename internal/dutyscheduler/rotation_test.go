@@ -0,0 +1,126 @@
+package dutyscheduler
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+func TestMergeRotation(t *testing.T) {
+	testCases := []struct {
+		saved      []string
+		current    string
+		configured []string
+		rotation   []string
+		position   uint64
+		diff       rotationDiff
+	}{
+		{
+			[]string{"test1", "test2", "test3"}, "test2", []string{"test3", "test2", "test1"},
+			[]string{"test1", "test2", "test3"}, 1, rotationDiff{},
+		},
+		{
+			[]string{"test1", "test2", "test3"}, "test3", []string{"test4", "test3", "test1", "test2"},
+			[]string{"test1", "test2", "test3", "test4"}, 2, rotationDiff{added: []string{"test4"}},
+		},
+		{
+			[]string{"test1", "test2", "test3"}, "test2", []string{"test1", "test3"},
+			[]string{"test1", "test3"}, 0, rotationDiff{removed: []string{"test2"}},
+		},
+		{
+			[]string{"test1", "test2", "test3"}, "test3", []string{"test2", "test3"},
+			[]string{"test2", "test3"}, 1, rotationDiff{removed: []string{"test1"}},
+		},
+		{
+			// the one following the removed current person is the first in the rotation
+			[]string{"test1", "test2", "test3"}, "test3", []string{"test1", "test2"},
+			[]string{"test1", "test2"}, 1, rotationDiff{removed: []string{"test3"}},
+		},
+		{
+			[]string{"test1", "test2"}, "", []string{"test2", "test3"},
+			[]string{"test2", "test3"}, math.MaxUint64,
+			rotationDiff{added: []string{"test3"}, removed: []string{"test1"}},
+		},
+		{
+			[]string{"test1"}, "test1", []string{"test2"},
+			[]string{"test2"}, math.MaxUint64,
+			rotationDiff{added: []string{"test2"}, removed: []string{"test1"}},
+		},
+	}
+
+	for i, testCase := range testCases {
+		rotation, position, diff := mergeRotation(
+			testCase.saved, testCase.current, testCase.configured,
+		)
+
+		assert.Equal(t, testCase.rotation, rotation, "test case %d", i)
+		assert.Equal(t, testCase.position, position, "test case %d", i)
+		assert.Equal(t, testCase.diff, diff, "test case %d", i)
+	}
+}
+
+func TestProjectRestoreChangedApplicants(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test1,test2,test3"),
+		Period:     string(EveryDay),
+	})
+
+	assert.Equal(t, "test1", project.NextPerson())
+	assert.Equal(t, "test2", project.NextPerson())
+
+	buf := &strings.Builder{}
+	assert.NoError(t, project.DumpState(buf))
+
+	state, err := statedumper.NewSchedulingState(strings.NewReader(buf.String()))
+	assert.NoError(t, err)
+
+	// test2 has left and test4 has joined at the top of the list
+	config := Config{
+		Name:       "test_project",
+		Applicants: ParseApplicants("test4,test3,test1"),
+		Period:     string(EveryDay),
+	}
+
+	restored := newTestProject(t, config)
+	assert.NoError(t, restored.RestoreState(state))
+
+	assert.Equal(t, []string{"test1", "test3", "test4"}, restored.dutyApplicants)
+	assert.Equal(t, "test3", restored.NextPerson())
+	assert.Equal(t, "test4", restored.NextPerson())
+	assert.Equal(t, "test1", restored.NextPerson())
+
+	// states without the order of the rotation keep the position
+	state.Rotation, state.CurrentName = nil, ""
+
+	restored = newTestProject(t, config)
+	assert.NoError(t, restored.RestoreState(state))
+
+	assert.Equal(t, "test3", restored.CurrentPerson())
+}
+
+func TestProjectRestoreChangedRegion(t *testing.T) {
+	project := newTestProject(t, newTestRegionsConfig())
+	project.regions[1].position = 1
+
+	buf := &strings.Builder{}
+	assert.NoError(t, project.DumpState(buf))
+
+	state, err := statedumper.NewSchedulingState(strings.NewReader(buf.String()))
+	assert.NoError(t, err)
+
+	config := newTestRegionsConfig()
+	config.Applicants = ParseApplicants("emea1,emea2,amer0,amer1,amer2")
+	config.Regions[1].Applicants = []string{"amer0", "amer1", "amer2"}
+
+	restored := newTestProject(t, config)
+	assert.NoError(t, restored.RestoreState(state))
+
+	assert.Equal(t, []string{"amer1", "amer2", "amer0"}, restored.regions[1].applicants)
+	assert.Equal(t, uint64(1), restored.regions[1].position)
+	assert.Equal(t, uint64(math.MaxUint64), restored.regions[0].position)
+}
@@ -0,0 +1,331 @@
+package dutyscheduler
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+const (
+	rulesNotOnParamName          = "not_on"
+	rulesMaxConsecutiveParamName = "max_consecutive"
+	rulesMinGapParamName         = "min_gap"
+)
+
+// protects the share of turns from floating point errors
+const weightEpsilon = 1e-9
+
+// RulesConfig restricts the shifts an applicant can take. The rules are not
+// followed only if no applicant satisfies them.
+type RulesConfig struct {
+	prefix string
+
+	// weekdays the applicant is not on duty
+	NotOn []string `mapstructure:"not_on"`
+	// shifts in a row at most, 0 is unlimited
+	MaxConsecutive int `mapstructure:"max_consecutive"`
+	// time off duty between shifts at least
+	MinGap time.Duration `mapstructure:"min_gap"`
+}
+
+func (cfg RulesConfig) paramWithPrefix() func(name string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
+
+func (cfg *RulesConfig) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	for i, day := range cfg.NotOn {
+		cfg.NotOn[i] = strings.ToLower(strings.TrimSpace(day))
+
+		if _, ok := weekdays[cfg.NotOn[i]]; !ok {
+			return fmt.Errorf(
+				"%s: unknown weekday '%s': %w",
+				paramNameFactory(rulesNotOnParamName), day, cfgUtil.ErrInvalidValue,
+			)
+		}
+	}
+
+	if cfg.MaxConsecutive < 0 {
+		return fmt.Errorf(
+			"%s: must not be negative: %w",
+			paramNameFactory(rulesMaxConsecutiveParamName), cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	if cfg.MinGap < 0 {
+		return fmt.Errorf(
+			"%s: must not be negative: %w",
+			paramNameFactory(rulesMinGapParamName), cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	return nil
+}
+
+func (cfg RulesConfig) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %v", paramNameFactory(rulesNotOnParamName), cfg.NotOn)
+	log.Printf("%s: %d", paramNameFactory(rulesMaxConsecutiveParamName), cfg.MaxConsecutive)
+	log.Printf("%s: %s", paramNameFactory(rulesMinGapParamName), cfg.MinGap)
+}
+
+func (cfg *RulesConfig) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
+
+// forbiddenDay returns the first weekday of the shift the applicant is not on duty,
+// empty if there is none. The day the shift ends is not counted unless the shift
+// starts on it.
+func (cfg RulesConfig) forbiddenDay(start, end time.Time, loc *time.Location) string {
+	if len(cfg.NotOn) == 0 {
+		return ""
+	}
+
+	start, end = start.In(loc), end.In(loc)
+
+	for date := start; !date.After(end); {
+		if date.After(start) && sameDay(date, end) {
+			break
+		}
+
+		for _, day := range cfg.NotOn {
+			if weekdays[day] == date.Weekday() {
+				return day
+			}
+		}
+
+		year, month, day := date.Date()
+		date = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+	}
+
+	return ""
+}
+
+// turnState is what the next person is picked by, schedule simulation picks
+// persons on a copy of the state of the project
+type turnState struct {
+	swaps    map[string]string
+	skips    map[string]bool
+	totals   map[string]statedumper.DutyTotals
+	lastDuty map[string]time.Time // applicant -> end of their last shift
+
+	current string // person on duty, empty if nobody
+	streak  int    // number of shifts in a row of the current person
+}
+
+// turn is the outcome of picking the next person
+type turn struct {
+	person             string // empty if nobody is available
+	substitute         bool   // the person takes the turn of another one due to a swap
+	skippedForVacation []string
+}
+
+// discards the reasons persons are passed over in schedule simulation
+var simulationLogger = &logrus.Logger{Out: io.Discard, Formatter: new(logrus.TextFormatter)}
+
+// pickPerson picks the person for the shift between start and end from the given
+// rotation and advances the position in it. Skips, swaps, vacations, weights and
+// the rules of applicants are considered, the reasons the applicants are passed over
+// are logged. If nobody satisfies the rules, the first available person takes the shift.
+func (p *Project) pickPerson(
+	s *turnState, applicants []string, position *uint64, start, end time.Time,
+	logger logrus.FieldLogger,
+) turn {
+	var (
+		result           turn
+		fallback         *turn
+		fallbackPosition uint64
+	)
+
+	passOver := func(person string, substitute bool, reason string, next uint64) {
+		logger.Infof("%s is skipped: %s", person, reason)
+
+		if fallback == nil {
+			fallback = &turn{person: person, substitute: substitute}
+			fallbackPosition = next
+		}
+	}
+
+	for _, next := range p.turnOrder(applicants, *position, s.totals, start) {
+		*position = next
+		person := applicants[next%uint64(len(applicants))]
+
+		if s.skips[person] {
+			logger.Infof("%s is skipped on request", person)
+			delete(s.skips, person)
+
+			continue
+		}
+
+		// the turn belongs to the place in the rotation, so it is passed before swaps
+		if !p.cfg.Fairness && !p.takesTurn(applicants, next) {
+			reason := fmt.Sprintf("takes fewer turns due to weight %g", p.applicants[person].Weight)
+			passOver(person, false, reason, next)
+
+			continue
+		}
+
+		substitute := false
+
+		if swapped, ok := s.swaps[person]; ok {
+			// the swap waits for the next turn unless the substitute can take this one
+			if p.shouldConsiderVacations() && p.isOnVacation(swapped, start) {
+				logger.Infof("%s is on vacation today, %s takes their own turn", swapped, person)
+			} else {
+				logger.Infof("%s takes the turn of %s due to a swap", swapped, person)
+
+				delete(s.swaps, person)
+				person = swapped
+				substitute = true
+			}
+		}
+
+		if p.shouldConsiderVacations() && p.isOnVacation(person, start) {
+			logger.Infof("%s is on vacation today, skipping", person)
+			result.skippedForVacation = append(result.skippedForVacation, person)
+
+			continue
+		}
+
+		if reason := p.breaksRules(s, person, start, end); len(reason) != 0 {
+			passOver(person, substitute, reason, next)
+
+			continue
+		}
+
+		result.person = person
+		result.substitute = substitute
+
+		return result
+	}
+
+	if fallback != nil {
+		logger.Warnf("nobody satisfies the rules, %s takes duty anyway", fallback.person)
+
+		*position = fallbackPosition
+		result.person = fallback.person
+		result.substitute = fallback.substitute
+	}
+
+	return result
+}
+
+// breaksRules returns the reason the person can not take the shift between start
+// and end according to their rules, empty if they can
+func (p *Project) breaksRules(s *turnState, person string, start, end time.Time) string {
+	applicant := p.applicants[person]
+	rules := applicant.Rules
+
+	if day := rules.forbiddenDay(start, end, applicant.location()); len(day) != 0 {
+		return fmt.Sprintf("is not on duty on %s", day)
+	}
+
+	if rules.MaxConsecutive > 0 && s.current == person && s.streak >= rules.MaxConsecutive {
+		return fmt.Sprintf("has been on duty for %d shifts in a row", s.streak)
+	}
+
+	if last, ok := s.lastDuty[person]; ok && rules.MinGap > 0 && start.Sub(last) < rules.MinGap {
+		return fmt.Sprintf(
+			"has been off duty for %s only, %s is required",
+			start.Sub(last).Round(time.Minute), rules.MinGap,
+		)
+	}
+
+	return ""
+}
+
+// takesTurn reports whether the applicant at the given position of the round
+// robin takes their turn. Applicants lighter than the heaviest one in the rotation
+// pass some of their turns so that their share of turns follows their weight.
+func (p *Project) takesTurn(applicants []string, position uint64) bool {
+	maxWeight := 0.0
+
+	for _, name := range applicants {
+		maxWeight = math.Max(maxWeight, p.applicants[name].Weight)
+	}
+
+	if maxWeight <= 0 {
+		return true
+	}
+
+	n := uint64(len(applicants))
+	share := p.applicants[applicants[position%n]].Weight / maxWeight
+	round := float64(position / n)
+
+	return math.Floor((round+1)*share+weightEpsilon) > math.Floor(round*share+weightEpsilon)
+}
+
+// weighted returns the totals of the applicant divided by their weight
+func (p *Project) weighted(person string, total statedumper.DutyTotals) statedumper.DutyTotals {
+	weight := p.applicants[person].Weight
+	if weight <= 0 {
+		return total
+	}
+
+	return statedumper.DutyTotals{
+		Duty:    time.Duration(float64(total.Duty) / weight),
+		DayOffs: time.Duration(float64(total.DayOffs) / weight),
+	}
+}
+
+// turnState returns the state of the project the next person is picked by, maps
+// are shared with the project unless copy is set
+func (p *Project) turnState(copyMaps bool) *turnState {
+	s := &turnState{
+		swaps:    p.swaps,
+		skips:    p.skips,
+		totals:   p.totals,
+		lastDuty: p.lastDuty,
+		streak:   p.streak,
+	}
+
+	// nobody has taken duty yet until the rotation has been started
+	if _, position := p.rotation(p.region); *position != math.MaxUint64 || len(p.substitute) != 0 {
+		s.current = p.currentPersonLocked()
+	}
+
+	if !copyMaps {
+		return s
+	}
+
+	s.swaps = make(map[string]string, len(p.swaps))
+	for person, substitute := range p.swaps {
+		s.swaps[person] = substitute
+	}
+
+	s.skips = make(map[string]bool, len(p.skips))
+	for person := range p.skips {
+		s.skips[person] = true
+	}
+
+	s.totals = p.copyTotals()
+
+	s.lastDuty = make(map[string]time.Time, len(p.lastDuty))
+	for person, t := range p.lastDuty {
+		s.lastDuty[person] = t
+	}
+
+	return s
+}
+
+// took updates the state after the person has taken the shift between start and end
+func (s *turnState) took(person string, end time.Time) {
+	if person == s.current {
+		s.streak++
+	} else {
+		s.current = person
+		s.streak = 1
+	}
+
+	s.lastDuty[person] = end
+}
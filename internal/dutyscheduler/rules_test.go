@@ -0,0 +1,194 @@
+package dutyscheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/cfg"
+	"github.com/gibsn/duty_bot/internal/statedumper"
+)
+
+func TestProjectWeights(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name: "test_project",
+		Applicants: Applicants{
+			{Name: "test1"}, {Name: "test2", Weight: 0.5}, {Name: "test3"},
+		},
+		Period: string(EveryDay),
+	})
+
+	monday := time.Date(2024, time.January, 8, 10, 0, 0, 0, time.Local)
+
+	// test2 takes every other turn of theirs
+	assert.Equal(t,
+		[]string{"test1", "test3", "test1", "test2", "test3", "test1", "test3", "test1", "test2"},
+		schedulePersons(project.schedule(monday, 9)),
+	)
+
+	// in fairness mode duty is balanced per unit of weight
+	project = newTestProject(t, Config{
+		Name: "test_project",
+		Applicants: Applicants{
+			{Name: "test1"}, {Name: "test2", Weight: 2},
+		},
+		Period:   string(EveryDay),
+		Fairness: true,
+	})
+
+	assert.Equal(t,
+		[]string{"test1", "test2", "test2", "test1", "test2"},
+		schedulePersons(project.schedule(monday, 5)),
+	)
+}
+
+func TestProjectWeightsRestart(t *testing.T) {
+	config := Config{
+		Name:       "test_project",
+		Applicants: Applicants{{Name: "test1"}, {Name: "test2", Weight: 0.5}, {Name: "test3"}},
+		Period:     string(EveryDay),
+	}
+
+	project := newTestProject(t, config)
+
+	var persons []string
+
+	// the bot restarts after every change
+	for i := 0; i < 9; i++ {
+		persons = append(persons, project.NextPerson())
+
+		state := dumpTestState(t, project)
+		project = newTestProject(t, config)
+		restoreTestState(t, project, state)
+	}
+
+	assert.Equal(t,
+		[]string{"test1", "test3", "test1", "test2", "test3", "test1", "test3", "test1", "test2"},
+		persons,
+	)
+}
+
+func TestProjectRulesNotOn(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name: "test_project",
+		Applicants: Applicants{
+			{Name: "test1", Rules: RulesConfig{NotOn: []string{"friday"}}},
+			{Name: "test2"},
+			{Name: "test3"},
+		},
+		Period: string(EveryDay),
+	})
+
+	tuesday := time.Date(2024, time.January, 9, 10, 0, 0, 0, time.Local)
+
+	// the shift of Thursday ends on Friday, which does not count
+	assert.Equal(t,
+		[]string{"test1", "test2", "test3", "test2", "test3", "test1"},
+		schedulePersons(project.schedule(tuesday, 6)),
+	)
+}
+
+func TestProjectRulesMaxConsecutive(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name: "test_project",
+		Applicants: Applicants{
+			{Name: "test1"},
+			{Name: "test2"},
+			{Name: "test3", Rules: RulesConfig{MaxConsecutive: 2}},
+		},
+		Period:   string(EveryDay),
+		Fairness: true,
+	})
+
+	// test3 has just returned from vacation
+	project.totals = map[string]statedumper.DutyTotals{
+		"test1": {Duty: 4 * day},
+		"test2": {Duty: 4 * day},
+	}
+
+	monday := time.Date(2024, time.January, 8, 10, 0, 0, 0, time.Local)
+
+	assert.Equal(t,
+		[]string{"test3", "test3", "test1", "test3", "test3", "test2"},
+		schedulePersons(project.schedule(monday, 6)),
+	)
+
+	// the streak is kept by the project
+	assert.Equal(t, "test3", project.NextPerson())
+	assert.Equal(t, "test3", project.NextPerson())
+	assert.Equal(t, 2, project.streak)
+	assert.Equal(t, "test1", project.NextPerson())
+	assert.Equal(t, 1, project.streak)
+}
+
+func TestProjectRulesMinGap(t *testing.T) {
+	project := newTestProject(t, Config{
+		Name: "test_project",
+		Applicants: Applicants{
+			{Name: "test1", Rules: RulesConfig{MinGap: 49 * time.Hour}},
+			{Name: "test2"},
+			{Name: "test3"},
+		},
+		Period: string(EveryDay),
+	})
+
+	monday := time.Date(2024, time.January, 8, 10, 0, 0, 0, time.Local)
+
+	assert.Equal(t,
+		[]string{"test1", "test2", "test3", "test2", "test3", "test1"},
+		schedulePersons(project.schedule(monday, 6)),
+	)
+}
+
+func TestProjectRulesFallback(t *testing.T) {
+	rules := RulesConfig{MinGap: 36 * time.Hour}
+
+	project := newTestProject(t, Config{
+		Name: "test_project",
+		Applicants: Applicants{
+			{Name: "test1", Rules: rules}, {Name: "test2", Rules: rules},
+		},
+		Period: string(EveryDay),
+	})
+
+	monday := time.Date(2024, time.January, 8, 10, 0, 0, 0, time.Local)
+
+	// nobody has had enough rest, so the rotation goes on as if there were no rules
+	assert.Equal(t,
+		[]string{"test1", "test2", "test1", "test2"},
+		schedulePersons(project.schedule(monday, 4)),
+	)
+}
+
+func TestConfigValidateRules(t *testing.T) {
+	invalid := []RulesConfig{
+		{NotOn: []string{"fri"}},
+		{MaxConsecutive: -1},
+		{MinGap: -time.Hour},
+	}
+
+	for _, rules := range invalid {
+		config := Config{Name: "test_project", Applicants: Applicants{{Name: "test1", Rules: rules}}}
+		assert.ErrorIs(t, config.Validate(), cfg.ErrInvalidValue, "%+v", rules)
+	}
+
+	config := Config{
+		Name:       "test_project",
+		Applicants: Applicants{{Name: "test1", Rules: RulesConfig{NotOn: []string{" Saturday "}}}},
+	}
+
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, []string{"saturday"}, config.Applicants[0].Rules.NotOn)
+}
+
+func TestRulesForbiddenDay(t *testing.T) {
+	rules := RulesConfig{NotOn: []string{"saturday"}}
+
+	friday := time.Date(2024, time.January, 12, 10, 0, 0, 0, time.UTC)
+
+	// the day the shift ends on is not counted
+	assert.Empty(t, rules.forbiddenDay(friday, friday.Add(24*time.Hour), time.UTC))
+	assert.Equal(t, "saturday", rules.forbiddenDay(friday, friday.Add(48*time.Hour), time.UTC))
+	assert.Equal(t, "saturday", rules.forbiddenDay(friday.Add(day), friday.Add(26*time.Hour), time.UTC))
+}
@@ -0,0 +1,34 @@
+package notifychannel
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	commandPrefix = "/"
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+)
+
+// CommandHandler processes a command received from a chat and returns
+// a text that must be sent back as a reply, empty text means no reply.
+// ErrUnknownCommand must be returned for commands that the handler does
+// not serve.
+type CommandHandler func(cmd string, args []string) (string, error)
+
+// ParseCommand splits the given message text into a command and its
+// arguments. It reports false in case the text is not a command.
+func ParseCommand(text string) (cmd string, args []string, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], commandPrefix) {
+		return "", nil, false
+	}
+
+	// commands may be addressed to a particular bot like '/duty@duty_bot'
+	cmd = strings.SplitN(fields[0], "@", 2)[0] // nolint: gomnd
+
+	return strings.ToLower(cmd), fields[1:], true
+}
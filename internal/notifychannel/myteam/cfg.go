@@ -11,6 +11,8 @@ import (
 const (
 	defaultMyTeamAPIURL  = "https://myteam.mail.ru/bot/v1/"
 	defaultMyTeamTimeout = 5 * time.Second
+	// long polling must finish before the http timeout fires
+	defaultMyTeamPollTime = 3 * time.Second
 )
 
 const (
@@ -20,6 +22,9 @@ const (
 	cfgMyTeamAPIURLTitle  = cfgMyTeamPrefix + ".api_url"
 	cfgMyTeamChatIDTitle  = cfgMyTeamPrefix + ".chat_id"
 	cfgMyTeamTimeoutTitle = cfgMyTeamPrefix + ".timeout"
+
+	cfgMyTeamCommandsTitle = cfgMyTeamPrefix + ".commands"
+	cfgMyTeamPollTimeTitle = cfgMyTeamPrefix + ".poll_time"
 )
 
 type Config struct {
@@ -29,6 +34,9 @@ type Config struct {
 	APIURL  string `mapstructure:"api_url"`
 	ChatID  string `mapstructure:"chat_id"`
 	Timeout time.Duration
+
+	Commands bool          // serve commands sent to the bot in the chat
+	PollTime time.Duration `mapstructure:"poll_time"`
 }
 
 func NewConfig(prefix string) Config {
@@ -59,6 +67,17 @@ func (cfg *Config) Validate() error {
 	if cfg.Timeout == 0 {
 		cfg.Timeout = defaultMyTeamTimeout
 	}
+	if cfg.PollTime == 0 {
+		cfg.PollTime = defaultMyTeamPollTime
+	}
+
+	if cfg.Commands && (cfg.PollTime < time.Second || cfg.PollTime >= cfg.Timeout) {
+		return fmt.Errorf(
+			"%s: must be in range [1s, %s): %w",
+			paramNameFactory(cfgMyTeamPollTimeTitle), paramNameFactory(cfgMyTeamTimeoutTitle),
+			cfgUtil.ErrInvalidValue,
+		)
+	}
 
 	return nil
 }
@@ -70,6 +89,11 @@ func (cfg Config) Print() {
 	log.Print(fmt.Sprintf("%s: %s", paramNameFactory(cfgMyTeamAPIURLTitle), cfg.APIURL))
 	log.Print(fmt.Sprintf("%s: %s", paramNameFactory(cfgMyTeamChatIDTitle), cfg.ChatID))
 	log.Print(fmt.Sprintf("%s: %s", paramNameFactory(cfgMyTeamTimeoutTitle), cfg.Timeout))
+	log.Print(fmt.Sprintf("%s: %t", paramNameFactory(cfgMyTeamCommandsTitle), cfg.Commands))
+
+	if cfg.Commands {
+		log.Print(fmt.Sprintf("%s: %s", paramNameFactory(cfgMyTeamPollTimeTitle), cfg.PollTime))
+	}
 }
 
 func (cfg *Config) SetPrefix(prefix string) {
@@ -1,17 +1,28 @@
 package myteam
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 
 	botgolang "github.com/mail-ru-im/bot-golang"
+	"github.com/sirupsen/logrus"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
 )
 
 type NotifyChannel struct {
+	cfg Config
+
 	bot *botgolang.Bot
 
 	chatID string
+
+	stopCommands context.CancelFunc
+	wg           sync.WaitGroup
 }
 
 func NewNotifyChannel(config Config) (*NotifyChannel, error) {
@@ -26,6 +37,7 @@ func NewNotifyChannel(config Config) (*NotifyChannel, error) {
 	log.Printf("info: myteam: connected to bot '%s'", bot.Info.Nick)
 
 	return &NotifyChannel{
+		cfg:    config,
 		bot:    bot,
 		chatID: config.ChatID,
 	}, nil
@@ -45,6 +57,77 @@ func (ch *NotifyChannel) Send(text string) error {
 	return nil
 }
 
+// ServeCommands starts listening to the bot events in background if commands
+// are enabled in config. Every command sent to the configured chat is passed
+// to the handler, the result is sent back as a reply.
+func (ch *NotifyChannel) ServeCommands(handler notifychannel.CommandHandler) {
+	if !ch.cfg.Commands || ch.stopCommands != nil {
+		return
+	}
+
+	// bot.GetUpdatesChannel polls for too long to fit into the configured timeout
+	// so updater is created manually
+	client := botgolang.NewClient(ch.cfg.APIURL, ch.cfg.Token, logrus.StandardLogger())
+	updater := botgolang.NewUpdater(client, int(ch.cfg.PollTime.Seconds()), logrus.StandardLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch.stopCommands = cancel
+
+	events := make(chan botgolang.Event)
+	go updater.RunUpdatesCheck(ctx, events)
+
+	ch.wg.Add(1)
+	go ch.commandsRoutine(events, handler)
+
+	log.Printf("info: myteam: serving commands in chat '%s'", ch.chatID)
+}
+
+func (ch *NotifyChannel) commandsRoutine(
+	events <-chan botgolang.Event, handler notifychannel.CommandHandler,
+) {
+	defer ch.wg.Done()
+
+	for event := range events {
+		if event.Type != botgolang.NEW_MESSAGE || event.Payload.Chat.ID != ch.chatID {
+			continue
+		}
+
+		cmd, args, ok := notifychannel.ParseCommand(event.Payload.Text)
+		if !ok {
+			continue
+		}
+
+		reply, err := handler(cmd, args)
+		if errors.Is(err, notifychannel.ErrUnknownCommand) {
+			continue
+		}
+		if err != nil {
+			reply = fmt.Sprintf("could not process %s: %v", cmd, err)
+		}
+		if len(reply) == 0 {
+			continue
+		}
+
+		if err := event.Payload.Message().Reply(reply); err != nil {
+			log.Printf("error: myteam: could not reply to %s in chat '%s': %v", cmd, ch.chatID, err)
+		}
+	}
+}
+
+// Mention returns a mention of the person that notifies them in the chat
+func (ch *NotifyChannel) Mention(person notifychannel.Person) string {
+	if len(person.MyTeam) == 0 {
+		return ""
+	}
+
+	return "@[" + person.MyTeam + "]"
+}
+
 func (ch *NotifyChannel) Shutdown() error {
+	if ch.stopCommands != nil {
+		ch.stopCommands()
+		ch.wg.Wait()
+	}
+
 	return nil
 }
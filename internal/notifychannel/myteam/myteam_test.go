@@ -0,0 +1,144 @@
+package myteam
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+const (
+	testChatID = "test_chat@chat.agent"
+)
+
+// fakeMyTeamAPI imitates MyTeam Bot API. It reports the given
+// messages as events once and records every message sent by the bot.
+type fakeMyTeamAPI struct {
+	incoming []string
+	sent     chan string
+	pinned   chan string
+}
+
+func newFakeMyTeamAPI(incoming ...string) *fakeMyTeamAPI {
+	return &fakeMyTeamAPI{
+		incoming: incoming,
+		sent:     make(chan string, 16),
+		pinned:   make(chan string, 16),
+	}
+}
+
+func (api *fakeMyTeamAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/self/get":
+		fmt.Fprint(w, `{"ok": true, "userId": "1000", "nick": "duty_bot"}`)
+	case "/events/get":
+		api.serveEvents(w, r)
+	case "/messages/sendText":
+		api.sent <- r.URL.Query().Get("replyMsgId") + ":" + r.URL.Query().Get("text")
+		fmt.Fprint(w, `{"ok": true, "msgId": "100"}`)
+	case "/chats/pinMessage":
+		api.pinned <- r.URL.Query().Get("msgId")
+		fmt.Fprint(w, `{"ok": true}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (api *fakeMyTeamAPI) serveEvents(w http.ResponseWriter, r *http.Request) {
+	// the initial request of the updater is ignored, reporting nothing
+	if r.URL.Query().Get("pollTime") == "0" || r.URL.Query().Get("lastEventId") != "0" {
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, `{"ok": true, "events": []}`)
+
+		return
+	}
+
+	fmt.Fprint(w, `{"ok": true, "events": [`)
+
+	for i, text := range api.incoming {
+		if i > 0 {
+			fmt.Fprint(w, ",")
+		}
+
+		fmt.Fprintf(w,
+			`{"eventId": %d, "type": "newMessage", "payload": {"msgId": "%d",`+
+				`"chat": {"chatId": "%s"}, "text": "%s"}}`,
+			i+1, i+1, testChatID, text,
+		)
+	}
+
+	fmt.Fprint(w, `]}`)
+}
+
+func newTestNotifyChannel(t *testing.T, api *fakeMyTeamAPI) *NotifyChannel {
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	config := NewConfig("test_project")
+	config.Token = "token"
+	config.ChatID = testChatID
+	config.APIURL = srv.URL
+	config.Commands = true
+	config.PollTime = time.Second
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	ch, err := NewNotifyChannel(config)
+	if err != nil {
+		t.Fatalf("could not create notify channel: %v", err)
+	}
+
+	return ch
+}
+
+func TestNotifyChannelSend(t *testing.T) {
+	api := newFakeMyTeamAPI()
+	ch := newTestNotifyChannel(t, api)
+
+	assert.NoError(t, ch.Send("test1 is on duty"))
+
+	assert.Equal(t, ":test1 is on duty", <-api.sent)
+	assert.Equal(t, "100", <-api.pinned)
+	assert.NoError(t, ch.Shutdown())
+}
+
+func TestNotifyChannelServeCommands(t *testing.T) {
+	api := newFakeMyTeamAPI("hello", "/duty", "/unknown", "/next@duty_bot 1")
+	ch := newTestNotifyChannel(t, api)
+
+	var commands []string
+
+	ch.ServeCommands(func(cmd string, args []string) (string, error) {
+		commands = append(commands, fmt.Sprint(cmd, args))
+
+		switch cmd {
+		case "/duty":
+			return "test1 is on duty", nil
+		case "/next":
+			return "test2 is next", nil
+		}
+
+		return "", notifychannel.ErrUnknownCommand
+	})
+
+	for _, expected := range []string{"2:test1 is on duty", "4:test2 is next"} {
+		select {
+		case reply := <-api.sent:
+			assert.Equal(t, expected, reply)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no reply within timeout, expected '%s'", expected)
+		}
+	}
+
+	assert.NoError(t, ch.Shutdown())
+
+	assert.Equal(t, []string{"/duty[]", "/unknown[]", "/next[1]"}, commands)
+	assert.Empty(t, api.sent)
+}
@@ -1 +1,16 @@
 package notifychannel
+
+import "time"
+
+// Notification describes an update of a project. Channels that need more than
+// the message text (like webhooks) may accept it instead of the text only.
+type Notification struct {
+	Project string `json:"project"`
+	Text    string `json:"text"` // message as it is sent to chats
+
+	NewPerson      string    `json:"new_person"`      // empty if the person of duty has not changed
+	PreviousPerson string    `json:"previous_person"` // empty if there was nobody on duty
+	NextChange     time.Time `json:"next_change"`
+
+	Roles map[string]string `json:"roles,omitempty"` // role -> person besides the primary one
+}
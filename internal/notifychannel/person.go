@@ -0,0 +1,22 @@
+package notifychannel
+
+// Person describes how a duty applicant is addressed in notifications.
+// Channels supporting mentions use the id of their messenger, if known.
+type Person struct {
+	Name        string // name of the applicant as it is listed in the project
+	DisplayName string // Name is displayed if empty
+
+	MyTeam   string // MyTeam user id, usually the email used to log in
+	Slack    string // Slack member id like U024BE7LH
+	Telegram string // Telegram username without @
+	Email    string
+}
+
+// String returns the name the person is displayed with
+func (p Person) String() string {
+	if len(p.DisplayName) != 0 {
+		return p.DisplayName
+	}
+
+	return p.Name
+}
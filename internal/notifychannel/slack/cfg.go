@@ -0,0 +1,101 @@
+package slack
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+)
+
+const (
+	defaultSlackAPIURL  = "https://slack.com/api"
+	defaultSlackTimeout = 5 * time.Second
+)
+
+const (
+	cfgSlackPrefix = "slack"
+
+	cfgSlackTokenTitle      = cfgSlackPrefix + ".token"
+	cfgSlackAPIURLTitle     = cfgSlackPrefix + ".api_url"
+	cfgSlackChannelIDTitle  = cfgSlackPrefix + ".channel_id"
+	cfgSlackWebhookURLTitle = cfgSlackPrefix + ".webhook_url"
+	cfgSlackTimeoutTitle    = cfgSlackPrefix + ".timeout"
+)
+
+// Config configures Slack notifications. Messages are either posted through
+// an incoming webhook or with chat.postMessage, the latter also pins them.
+type Config struct {
+	prefix string
+
+	Token     string
+	APIURL    string `mapstructure:"api_url"`
+	ChannelID string `mapstructure:"channel_id"`
+
+	WebhookURL string `mapstructure:"webhook_url"`
+
+	Timeout time.Duration
+}
+
+func NewConfig(prefix string) Config {
+	config := Config{
+		prefix: prefix,
+	}
+
+	return config
+}
+
+func (cfg *Config) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	if len(cfg.WebhookURL) == 0 {
+		if len(cfg.Token) == 0 {
+			return fmt.Errorf(
+				"%s or %s: %w",
+				paramNameFactory(cfgSlackTokenTitle), paramNameFactory(cfgSlackWebhookURLTitle),
+				cfgUtil.ErrMustNotBeEmpty,
+			)
+		}
+		if len(cfg.ChannelID) == 0 {
+			return fmt.Errorf(
+				"%s: %w", paramNameFactory(cfgSlackChannelIDTitle), cfgUtil.ErrMustNotBeEmpty,
+			)
+		}
+	}
+
+	if len(cfg.APIURL) == 0 {
+		cfg.APIURL = defaultSlackAPIURL
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultSlackTimeout
+	}
+
+	return nil
+}
+
+// UseWebhook reports whether messages are sent through an incoming webhook
+func (cfg Config) UseWebhook() bool {
+	return len(cfg.WebhookURL) != 0
+}
+
+func (cfg Config) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	// token and webhook url are sensitive
+	if cfg.UseWebhook() {
+		log.Printf("%s: ***", paramNameFactory(cfgSlackWebhookURLTitle))
+	} else {
+		log.Printf("%s: %s", paramNameFactory(cfgSlackAPIURLTitle), cfg.APIURL)
+		log.Printf("%s: %s", paramNameFactory(cfgSlackChannelIDTitle), cfg.ChannelID)
+	}
+
+	log.Printf("%s: %s", paramNameFactory(cfgSlackTimeoutTitle), cfg.Timeout)
+}
+
+func (cfg *Config) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
+
+func (cfg Config) paramWithPrefix() func(param string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
@@ -0,0 +1,154 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+const (
+	postMessageMethod = "/chat.postMessage"
+	pinMethod         = "/pins.add"
+)
+
+var (
+	ErrAPI = errors.New("slack api error")
+)
+
+// NotifyChannel sends notifications to a Slack channel.
+type NotifyChannel struct {
+	cfg Config
+
+	httpClient *http.Client
+}
+
+func NewNotifyChannel(config Config) (*NotifyChannel, error) {
+	ch := &NotifyChannel{
+		cfg: config,
+		httpClient: &http.Client{
+			Timeout: config.Timeout,
+		},
+	}
+
+	if config.UseWebhook() {
+		log.Print("info: slack: will send messages through incoming webhook")
+	} else {
+		log.Printf("info: slack: will send messages to channel '%s'", config.ChannelID)
+	}
+
+	return ch, nil
+}
+
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+func (ch *NotifyChannel) Send(text string) error {
+	if ch.cfg.UseWebhook() {
+		return ch.sendWebhook(text)
+	}
+
+	resp, err := ch.callAPI(postMessageMethod, map[string]string{
+		"channel": ch.cfg.ChannelID,
+		"text":    text,
+	})
+	if err != nil {
+		return fmt.Errorf("could not send message to channel '%s': %w", ch.cfg.ChannelID, err)
+	}
+
+	_, err = ch.callAPI(pinMethod, map[string]string{
+		"channel":   resp.Channel,
+		"timestamp": resp.TS,
+	})
+	if err != nil {
+		log.Printf("error: slack: could not pin message in channel '%s': %v", ch.cfg.ChannelID, err)
+	}
+
+	return nil
+}
+
+func (ch *NotifyChannel) sendWebhook(text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("could not marshal message: %w", err)
+	}
+
+	resp, err := ch.httpClient.Post(ch.cfg.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not send message to webhook: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024)) // nolint: gomnd, errcheck
+
+		return fmt.Errorf(
+			"webhook returned %d '%s': %w",
+			resp.StatusCode, strings.TrimSpace(string(respBody)), ErrAPI,
+		)
+	}
+
+	return nil
+}
+
+func (ch *NotifyChannel) callAPI(method string, params map[string]string) (*apiResponse, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ch.cfg.APIURL+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+ch.cfg.Token)
+
+	resp, err := ch.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", method, err)
+	}
+
+	defer resp.Body.Close()
+
+	apiResp := &apiResponse{}
+
+	if err := json.NewDecoder(resp.Body).Decode(apiResp); err != nil {
+		return nil, fmt.Errorf("%s: could not decode response with status %d: %w",
+			method, resp.StatusCode, err,
+		)
+	}
+
+	if !apiResp.OK {
+		return nil, fmt.Errorf("%s: %s: %w", method, apiResp.Error, ErrAPI)
+	}
+
+	return apiResp, nil
+}
+
+// Mention returns a mention of the person that notifies them in the channel
+func (ch *NotifyChannel) Mention(person notifychannel.Person) string {
+	if len(person.Slack) == 0 {
+		return ""
+	}
+
+	return "<@" + person.Slack + ">"
+}
+
+func (ch *NotifyChannel) Shutdown() error {
+	return nil
+}
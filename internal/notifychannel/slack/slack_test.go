@@ -0,0 +1,147 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testToken     = "xoxb-token"
+	testChannelID = "C0000000001"
+)
+
+// fakeSlackAPI imitates Slack Web API and incoming webhooks recording
+// every posted and pinned message.
+type fakeSlackAPI struct {
+	posted chan string
+	pinned chan string
+
+	failPost bool
+}
+
+func newFakeSlackAPI() *fakeSlackAPI {
+	return &fakeSlackAPI{
+		posted: make(chan string, 16),
+		pinned: make(chan string, 16),
+	}
+}
+
+func (api *fakeSlackAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Path == "/webhook" {
+		api.posted <- params["text"]
+		fmt.Fprint(w, "ok")
+
+		return
+	}
+
+	if r.URL.Path != "/chat.postMessage" && r.URL.Path != "/pins.add" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+testToken {
+		fmt.Fprint(w, `{"ok": false, "error": "invalid_auth"}`)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/chat.postMessage":
+		if api.failPost {
+			fmt.Fprint(w, `{"ok": false, "error": "channel_not_found"}`)
+			return
+		}
+
+		api.posted <- params["channel"] + ":" + params["text"]
+		fmt.Fprintf(w, `{"ok": true, "channel": "%s", "ts": "1600000000.000100"}`, params["channel"])
+	case "/pins.add":
+		api.pinned <- params["channel"] + ":" + params["timestamp"]
+		fmt.Fprint(w, `{"ok": true}`)
+	}
+}
+
+func newTestNotifyChannel(t *testing.T, config Config) *NotifyChannel {
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	ch, err := NewNotifyChannel(config)
+	if err != nil {
+		t.Fatalf("could not create notify channel: %v", err)
+	}
+
+	return ch
+}
+
+func TestNotifyChannelSend(t *testing.T) {
+	api := newFakeSlackAPI()
+
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	config := NewConfig("test_project")
+	config.Token = testToken
+	config.ChannelID = testChannelID
+	config.APIURL = srv.URL
+
+	ch := newTestNotifyChannel(t, config)
+
+	assert.NoError(t, ch.Send("test1 is on duty"))
+	assert.Equal(t, testChannelID+":test1 is on duty", <-api.posted)
+	assert.Equal(t, testChannelID+":1600000000.000100", <-api.pinned)
+
+	api.failPost = true
+
+	assert.ErrorIs(t, ch.Send("test2 is on duty"), ErrAPI)
+	assert.Empty(t, api.pinned)
+
+	assert.NoError(t, ch.Shutdown())
+}
+
+func TestNotifyChannelSendWebhook(t *testing.T) {
+	api := newFakeSlackAPI()
+
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	config := NewConfig("test_project")
+	config.WebhookURL = srv.URL + "/webhook"
+
+	ch := newTestNotifyChannel(t, config)
+
+	assert.NoError(t, ch.Send("test1 is on duty"))
+	assert.Equal(t, "test1 is on duty", <-api.posted)
+	assert.Empty(t, api.pinned)
+
+	config.WebhookURL = srv.URL + "/unknown"
+	ch = newTestNotifyChannel(t, config)
+
+	assert.ErrorIs(t, ch.Send("test1 is on duty"), ErrAPI)
+}
+
+func TestConfigValidate(t *testing.T) {
+	config := NewConfig("test_project")
+	assert.Error(t, config.Validate())
+
+	config.Token = testToken
+	assert.Error(t, config.Validate())
+
+	config.ChannelID = testChannelID
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, defaultSlackAPIURL, config.APIURL)
+	assert.Equal(t, defaultSlackTimeout, config.Timeout)
+
+	config = NewConfig("test_project")
+	config.WebhookURL = "https://hooks.slack.com/services/T/B/X"
+	assert.NoError(t, config.Validate())
+}
@@ -0,0 +1,159 @@
+package smtp
+
+import (
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+)
+
+// TLSMode defines how the connection to the SMTP server is secured.
+type TLSMode string
+
+const (
+	TLSModeNone     TLSMode = "none"
+	TLSModeSTARTTLS TLSMode = "starttls"
+	TLSModeTLS      TLSMode = "tls" // implicit TLS, usually on port 465
+)
+
+const (
+	defaultSMTPTLSMode = TLSModeSTARTTLS
+	defaultSMTPSubject = "Duty update"
+	defaultSMTPTimeout = 10 * time.Second
+)
+
+var defaultSMTPPorts = map[TLSMode]int{
+	TLSModeNone:     25,  // nolint: gomnd
+	TLSModeSTARTTLS: 587, // nolint: gomnd
+	TLSModeTLS:      465, // nolint: gomnd
+}
+
+const (
+	cfgSMTPPrefix = "smtp"
+
+	cfgSMTPHostTitle     = cfgSMTPPrefix + ".host"
+	cfgSMTPPortTitle     = cfgSMTPPrefix + ".port"
+	cfgSMTPTLSModeTitle  = cfgSMTPPrefix + ".tls_mode"
+	cfgSMTPUsernameTitle = cfgSMTPPrefix + ".username"
+	cfgSMTPFromTitle     = cfgSMTPPrefix + ".from"
+	cfgSMTPToTitle       = cfgSMTPPrefix + ".to"
+	cfgSMTPSubjectTitle  = cfgSMTPPrefix + ".subject"
+	cfgSMTPTimeoutTitle  = cfgSMTPPrefix + ".timeout"
+)
+
+type Config struct {
+	prefix string
+
+	Host    string
+	Port    int
+	TLSMode TLSMode `mapstructure:"tls_mode"`
+
+	// PLAIN authentication is used if username is set
+	Username string
+	Password string
+
+	From    string
+	To      []string
+	Subject string
+
+	Timeout time.Duration
+}
+
+func NewConfig(prefix string) Config {
+	config := Config{
+		prefix: prefix,
+	}
+
+	return config
+}
+
+func (cfg *Config) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	if len(cfg.Host) == 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(cfgSMTPHostTitle), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+
+	if len(cfg.TLSMode) == 0 {
+		cfg.TLSMode = defaultSMTPTLSMode
+	}
+
+	cfg.TLSMode = TLSMode(strings.ToLower(string(cfg.TLSMode)))
+
+	defaultPort, ok := defaultSMTPPorts[cfg.TLSMode]
+	if !ok {
+		return fmt.Errorf(
+			"%s '%s': %w", paramNameFactory(cfgSMTPTLSModeTitle), cfg.TLSMode, cfgUtil.ErrNotSupported,
+		)
+	}
+
+	if cfg.Port == 0 {
+		cfg.Port = defaultPort
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(cfgSMTPPortTitle), cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	if len(cfg.From) == 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(cfgSMTPFromTitle), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return fmt.Errorf(
+			"%s: %v: %w", paramNameFactory(cfgSMTPFromTitle), err, cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	if len(cfg.To) == 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(cfgSMTPToTitle), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+
+	for _, to := range cfg.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf(
+				"%s '%s': %v: %w", paramNameFactory(cfgSMTPToTitle), to, err, cfgUtil.ErrInvalidValue,
+			)
+		}
+	}
+
+	if len(cfg.Subject) == 0 {
+		cfg.Subject = defaultSMTPSubject
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+
+	return nil
+}
+
+func (cfg Config) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	log.Printf("%s: %s", paramNameFactory(cfgSMTPHostTitle), cfg.Host)
+	log.Printf("%s: %d", paramNameFactory(cfgSMTPPortTitle), cfg.Port)
+	log.Printf("%s: %s", paramNameFactory(cfgSMTPTLSModeTitle), cfg.TLSMode)
+	// password is sensitive
+	log.Printf("%s: %s", paramNameFactory(cfgSMTPUsernameTitle), cfg.Username)
+	log.Printf("%s: %s", paramNameFactory(cfgSMTPFromTitle), cfg.From)
+	log.Printf("%s: %s", paramNameFactory(cfgSMTPToTitle), strings.Join(cfg.To, ", "))
+	log.Printf("%s: %s", paramNameFactory(cfgSMTPSubjectTitle), cfg.Subject)
+	log.Printf("%s: %s", paramNameFactory(cfgSMTPTimeoutTitle), cfg.Timeout)
+}
+
+func (cfg *Config) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
+
+func (cfg Config) paramWithPrefix() func(param string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
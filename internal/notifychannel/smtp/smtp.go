@@ -0,0 +1,210 @@
+package smtp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	netsmtp "net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+// NotifyChannel emails every update to the configured recipients. A new
+// connection is established for every message.
+type NotifyChannel struct {
+	cfg Config
+
+	addr string
+	from *mail.Address
+	to   []*mail.Address
+}
+
+func NewNotifyChannel(config Config) (*NotifyChannel, error) {
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	to := make([]*mail.Address, 0, len(config.To))
+
+	for _, addr := range config.To {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient address: %w", err)
+		}
+
+		to = append(to, parsed)
+	}
+
+	ch := &NotifyChannel{
+		cfg:  config,
+		addr: net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		from: from,
+		to:   to,
+	}
+
+	log.Printf("info: smtp: will send emails to %d recipients via %s", len(to), ch.addr)
+
+	return ch, nil
+}
+
+func (ch *NotifyChannel) Send(text string) error {
+	return ch.send(ch.cfg.Subject, text)
+}
+
+// SendNotification sends the text of the notification with the project
+// name in the subject.
+func (ch *NotifyChannel) SendNotification(n notifychannel.Notification) error {
+	subject := ch.cfg.Subject
+	if len(n.Project) != 0 {
+		subject = "[" + n.Project + "] " + subject
+	}
+
+	return ch.send(subject, n.Text)
+}
+
+func (ch *NotifyChannel) send(subject, text string) error {
+	msg, err := ch.composeMessage(subject, text)
+	if err != nil {
+		return fmt.Errorf("could not compose message: %w", err)
+	}
+
+	client, err := ch.dial()
+	if err != nil {
+		return fmt.Errorf("could not connect to %s: %w", ch.addr, err)
+	}
+
+	defer client.Close()
+
+	if err := client.Mail(ch.from.Address); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+
+	for _, to := range ch.to {
+		if err := client.Rcpt(to.Address); err != nil {
+			return fmt.Errorf("RCPT TO <%s> failed: %w", to.Address, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA failed: %w", err)
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("could not write message: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message was not accepted: %w", err)
+	}
+
+	if err := client.Quit(); err != nil {
+		log.Printf("error: smtp: QUIT failed: %v", err)
+	}
+
+	return nil
+}
+
+// dial connects to the server, secures the connection and authenticates
+// according to config.
+func (ch *NotifyChannel) dial() (*netsmtp.Client, error) {
+	dialer := &net.Dialer{Timeout: ch.cfg.Timeout}
+	tlsConfig := &tls.Config{ServerName: ch.cfg.Host} // nolint: gosec
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if ch.cfg.TLSMode == TLSModeTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", ch.addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", ch.addr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the whole conversation must fit into the timeout
+	if err := conn.SetDeadline(time.Now().Add(ch.cfg.Timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client, err := netsmtp.NewClient(conn, ch.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if ch.cfg.TLSMode == TLSModeSTARTTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if len(ch.cfg.Username) != 0 {
+		auth := netsmtp.PlainAuth("", ch.cfg.Username, ch.cfg.Password, ch.cfg.Host)
+
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("AUTH failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+func (ch *NotifyChannel) composeMessage(subject, text string) ([]byte, error) {
+	to := make([]string, 0, len(ch.to))
+	for _, addr := range ch.to {
+		to = append(to, addr.String())
+	}
+
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintf(buf, "From: %s\r\n", ch.from)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprint(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprint(buf, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprint(buf, "Content-Transfer-Encoding: quoted-printable\r\n")
+	fmt.Fprint(buf, "\r\n")
+
+	w := quotedprintable.NewWriter(buf)
+
+	if _, err := w.Write([]byte(text)); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Mention returns the person along with their email address if it is known
+func (ch *NotifyChannel) Mention(person notifychannel.Person) string {
+	if len(person.Email) == 0 {
+		return ""
+	}
+
+	return person.String() + " <" + person.Email + ">"
+}
+
+func (ch *NotifyChannel) Shutdown() error {
+	return nil
+}
@@ -0,0 +1,206 @@
+package smtp
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+// envelope is a message received by fakeSMTPServer
+type envelope struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer is a minimal SMTP server accepting messages without TLS.
+type fakeSMTPServer struct {
+	ln       net.Listener
+	received chan envelope
+
+	rejectRcpt string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	srv := &fakeSMTPServer{
+		ln:       ln,
+		received: make(chan envelope, 16),
+	}
+
+	t.Cleanup(func() { ln.Close() })
+
+	go srv.serve()
+
+	return srv
+}
+
+func (srv *fakeSMTPServer) port() int {
+	return srv.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (srv *fakeSMTPServer) serve() {
+	for {
+		conn, err := srv.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		go srv.handle(textproto.NewConn(conn))
+	}
+}
+
+func (srv *fakeSMTPServer) handle(conn *textproto.Conn) {
+	defer conn.Close()
+
+	e := envelope{}
+
+	conn.PrintfLine("220 localhost ESMTP") // nolint: errcheck
+
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb := strings.ToUpper(strings.Fields(line + " ")[0])
+		arg := strings.TrimSpace(line[len(verb):])
+
+		switch verb {
+		case "EHLO":
+			conn.PrintfLine("250-localhost\r\n250 AUTH PLAIN") // nolint: errcheck
+		case "AUTH":
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			e.auth = strings.ReplaceAll(string(decoded), "\x00", ":")
+			conn.PrintfLine("235 authenticated") // nolint: errcheck
+		case "MAIL":
+			e.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			conn.PrintfLine("250 ok") // nolint: errcheck
+		case "RCPT":
+			to := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if to == srv.rejectRcpt {
+				conn.PrintfLine("550 no such user") // nolint: errcheck
+				continue
+			}
+
+			e.to = append(e.to, to)
+			conn.PrintfLine("250 ok") // nolint: errcheck
+		case "DATA":
+			conn.PrintfLine("354 go ahead") // nolint: errcheck
+
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+
+			e.data = string(data)
+			srv.received <- e
+
+			conn.PrintfLine("250 queued") // nolint: errcheck
+		case "QUIT":
+			conn.PrintfLine("221 bye") // nolint: errcheck
+			return
+		default:
+			conn.PrintfLine("502 not implemented") // nolint: errcheck
+		}
+	}
+}
+
+func newTestNotifyChannel(t *testing.T, srv *fakeSMTPServer) *NotifyChannel {
+	config := NewConfig("test_project")
+	config.Host = "127.0.0.1"
+	config.Port = srv.port()
+	config.TLSMode = TLSModeNone
+	config.Username = "duty_bot"
+	config.Password = "secret"
+	config.From = "Duty Bot <duty_bot@example.com>"
+	config.To = []string{"test1@example.com", "Test 2 <test2@example.com>"}
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	ch, err := NewNotifyChannel(config)
+	if err != nil {
+		t.Fatalf("could not create notify channel: %v", err)
+	}
+
+	return ch
+}
+
+func TestNotifyChannelSendNotification(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	ch := newTestNotifyChannel(t, srv)
+
+	err := ch.SendNotification(notifychannel.Notification{
+		Project: "test_project",
+		Text:    "Тест1 is on duty",
+	})
+	assert.NoError(t, err)
+
+	e := <-srv.received
+	assert.Equal(t, ":duty_bot:secret", e.auth)
+	assert.Equal(t, "duty_bot@example.com", e.from)
+	assert.Equal(t, []string{"test1@example.com", "test2@example.com"}, e.to)
+
+	msg, err := mail.ReadMessage(strings.NewReader(e.data))
+	if err != nil {
+		t.Fatalf("could not parse message: %v", err)
+	}
+
+	assert.Equal(t, `"Duty Bot" <duty_bot@example.com>`, msg.Header.Get("From"))
+	assert.Equal(t, `<test1@example.com>, "Test 2" <test2@example.com>`, msg.Header.Get("To"))
+	assert.Equal(t, "[test_project] Duty update", msg.Header.Get("Subject"))
+
+	body, err := ioutil.ReadAll(quotedprintable.NewReader(msg.Body))
+	assert.NoError(t, err)
+	assert.Equal(t, "Тест1 is on duty", strings.TrimRight(string(body), "\r\n"))
+}
+
+func TestNotifyChannelSendRejected(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	srv.rejectRcpt = "test2@example.com"
+
+	ch := newTestNotifyChannel(t, srv)
+
+	assert.Error(t, ch.Send("test1 is on duty"))
+	assert.Empty(t, srv.received)
+}
+
+func TestConfigValidate(t *testing.T) {
+	config := NewConfig("test_project")
+	assert.Error(t, config.Validate())
+
+	config.Host = "smtp.example.com"
+	config.From = "duty_bot@example.com"
+	config.To = []string{"test1@example.com"}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, TLSModeSTARTTLS, config.TLSMode)
+	assert.Equal(t, 587, config.Port)
+
+	config.TLSMode = "ssl"
+	assert.Error(t, config.Validate())
+
+	config.TLSMode = "TLS"
+	config.Port = 0
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, TLSModeTLS, config.TLSMode)
+	assert.Equal(t, 465, config.Port)
+
+	config.To = []string{"not an address"}
+	assert.Error(t, config.Validate())
+}
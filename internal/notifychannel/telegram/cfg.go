@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+)
+
+const (
+	defaultTelegramAPIURL  = "https://api.telegram.org"
+	defaultTelegramTimeout = 5 * time.Second
+)
+
+const (
+	cfgTelegramPrefix = "telegram"
+
+	cfgTelegramTokenTitle   = cfgTelegramPrefix + ".token"
+	cfgTelegramAPIURLTitle  = cfgTelegramPrefix + ".api_url"
+	cfgTelegramChatIDTitle  = cfgTelegramPrefix + ".chat_id"
+	cfgTelegramTimeoutTitle = cfgTelegramPrefix + ".timeout"
+)
+
+type Config struct {
+	prefix string
+
+	Token   string
+	APIURL  string `mapstructure:"api_url"`
+	ChatID  string `mapstructure:"chat_id"` // numeric id or @channelusername
+	Timeout time.Duration
+}
+
+func NewConfig(prefix string) Config {
+	config := Config{
+		prefix: prefix,
+	}
+
+	return config
+}
+
+func (cfg *Config) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	if len(cfg.Token) == 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(cfgTelegramTokenTitle), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+	if len(cfg.ChatID) == 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(cfgTelegramChatIDTitle), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+
+	if len(cfg.APIURL) == 0 {
+		cfg.APIURL = defaultTelegramAPIURL
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTelegramTimeout
+	}
+
+	return nil
+}
+
+func (cfg Config) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	// token is sensitive
+	log.Printf("%s: %s", paramNameFactory(cfgTelegramAPIURLTitle), cfg.APIURL)
+	log.Printf("%s: %s", paramNameFactory(cfgTelegramChatIDTitle), cfg.ChatID)
+	log.Printf("%s: %s", paramNameFactory(cfgTelegramTimeoutTitle), cfg.Timeout)
+}
+
+func (cfg *Config) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
+
+func (cfg Config) paramWithPrefix() func(param string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
@@ -0,0 +1,128 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+const (
+	sendMessageMethod = "sendMessage"
+	pinMethod         = "pinChatMessage"
+)
+
+var (
+	ErrAPI = errors.New("telegram bot api error")
+)
+
+// NotifyChannel sends notifications to a Telegram chat on behalf of a bot.
+type NotifyChannel struct {
+	cfg Config
+
+	httpClient *http.Client
+}
+
+func NewNotifyChannel(config Config) (*NotifyChannel, error) {
+	log.Printf("info: telegram: will send messages to chat '%s'", config.ChatID)
+
+	return &NotifyChannel{
+		cfg: config,
+		httpClient: &http.Client{
+			Timeout: config.Timeout,
+		},
+	}, nil
+}
+
+type apiResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+
+	Result json.RawMessage `json:"result"`
+}
+
+type message struct {
+	MessageID int64 `json:"message_id"`
+}
+
+func (ch *NotifyChannel) Send(text string) error {
+	msg := message{}
+
+	err := ch.callAPI(sendMessageMethod, map[string]interface{}{
+		"chat_id": ch.cfg.ChatID,
+		"text":    text,
+	}, &msg)
+	if err != nil {
+		return fmt.Errorf("could not send message to chat '%s': %w", ch.cfg.ChatID, err)
+	}
+
+	err = ch.callAPI(pinMethod, map[string]interface{}{
+		"chat_id":    ch.cfg.ChatID,
+		"message_id": msg.MessageID,
+	}, nil)
+	if err != nil {
+		log.Printf("error: telegram: could not pin message in chat '%s': %v", ch.cfg.ChatID, err)
+	}
+
+	return nil
+}
+
+// callAPI calls the given method of Telegram Bot API and decodes the result
+// into the given value if it is not nil.
+func (ch *NotifyChannel) callAPI(
+	method string, params map[string]interface{}, result interface{},
+) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("could not marshal request: %w", err)
+	}
+
+	url := strings.TrimSuffix(ch.cfg.APIURL, "/") + "/bot" + ch.cfg.Token + "/" + method
+
+	resp, err := ch.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// the error contains url with the token
+		return fmt.Errorf("%s failed: %w", method, errors.Unwrap(err))
+	}
+
+	defer resp.Body.Close()
+
+	apiResp := &apiResponse{}
+
+	if err := json.NewDecoder(resp.Body).Decode(apiResp); err != nil {
+		return fmt.Errorf("%s: could not decode response with status %d: %w",
+			method, resp.StatusCode, err,
+		)
+	}
+
+	if !apiResp.OK {
+		return fmt.Errorf("%s: %s: %w", method, apiResp.Description, ErrAPI)
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(apiResp.Result, result); err != nil {
+			return fmt.Errorf("%s: could not decode result: %w", method, err)
+		}
+	}
+
+	return nil
+}
+
+// Mention returns a mention of the person that notifies them in the chat.
+// Messages are sent as plain text, so only users with a username can be mentioned.
+func (ch *NotifyChannel) Mention(person notifychannel.Person) string {
+	if len(person.Telegram) == 0 {
+		return ""
+	}
+
+	return "@" + person.Telegram
+}
+
+func (ch *NotifyChannel) Shutdown() error {
+	return nil
+}
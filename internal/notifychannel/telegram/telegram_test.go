@@ -0,0 +1,123 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testToken  = "123456:token"
+	testChatID = "-1001234567890"
+)
+
+// fakeTelegramAPI imitates Telegram Bot API recording every sent and pinned message.
+type fakeTelegramAPI struct {
+	sent   chan string
+	pinned chan string
+
+	failPin bool
+}
+
+func newFakeTelegramAPI() *fakeTelegramAPI {
+	return &fakeTelegramAPI{
+		sent:   make(chan string, 16),
+		pinned: make(chan string, 16),
+	}
+}
+
+func (api *fakeTelegramAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := map[string]interface{}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"ok": false, "description": "Bad Request: invalid json"}`)
+
+		return
+	}
+
+	switch r.URL.Path {
+	case "/bot" + testToken + "/sendMessage":
+		if params["chat_id"] != testChatID {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"ok": false, "description": "Bad Request: chat not found"}`)
+
+			return
+		}
+
+		api.sent <- fmt.Sprint(params["text"])
+		fmt.Fprint(w, `{"ok": true, "result": {"message_id": 42, "text": "whatever"}}`)
+	case "/bot" + testToken + "/pinChatMessage":
+		if api.failPin {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"ok": false, "description": "Bad Request: not enough rights"}`)
+
+			return
+		}
+
+		api.pinned <- fmt.Sprint(params["message_id"])
+		fmt.Fprint(w, `{"ok": true, "result": true}`)
+	default:
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"ok": false, "description": "Unauthorized"}`)
+	}
+}
+
+func newTestNotifyChannel(t *testing.T, apiURL, chatID string) *NotifyChannel {
+	config := NewConfig("test_project")
+	config.Token = testToken
+	config.ChatID = chatID
+	config.APIURL = apiURL
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	ch, err := NewNotifyChannel(config)
+	if err != nil {
+		t.Fatalf("could not create notify channel: %v", err)
+	}
+
+	return ch
+}
+
+func TestNotifyChannelSend(t *testing.T) {
+	api := newFakeTelegramAPI()
+
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	ch := newTestNotifyChannel(t, srv.URL, testChatID)
+
+	assert.NoError(t, ch.Send("test1 is on duty"))
+	assert.Equal(t, "test1 is on duty", <-api.sent)
+	assert.Equal(t, "42", <-api.pinned)
+
+	// failing to pin is not fatal
+	api.failPin = true
+
+	assert.NoError(t, ch.Send("test2 is on duty"))
+	assert.Equal(t, "test2 is on duty", <-api.sent)
+	assert.Empty(t, api.pinned)
+
+	ch = newTestNotifyChannel(t, srv.URL, "unknown")
+
+	assert.ErrorIs(t, ch.Send("test1 is on duty"), ErrAPI)
+	assert.Empty(t, api.sent)
+}
+
+func TestConfigValidate(t *testing.T) {
+	config := NewConfig("test_project")
+	assert.Error(t, config.Validate())
+
+	config.Token = testToken
+	assert.Error(t, config.Validate())
+
+	config.ChatID = testChatID
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, defaultTelegramAPIURL, config.APIURL)
+	assert.Equal(t, defaultTelegramTimeout, config.Timeout)
+}
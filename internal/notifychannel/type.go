@@ -5,9 +5,13 @@ import "github.com/gibsn/duty_bot/internal/cfg"
 type Type string
 
 const (
-	EmptyChannelType  Type = "empty"
-	StdOutChannelType Type = "stdout" // mostly for debugging purposes
-	MyTeamChannelType Type = "myteam"
+	EmptyChannelType    Type = "empty"
+	StdOutChannelType   Type = "stdout" // mostly for debugging purposes
+	MyTeamChannelType   Type = "myteam"
+	SlackChannelType    Type = "slack"
+	TelegramChannelType Type = "telegram"
+	WebhookChannelType  Type = "webhook"
+	SMTPChannelType     Type = "smtp"
 )
 
 func (ch Type) Validate() error {
@@ -17,6 +21,14 @@ func (ch Type) Validate() error {
 	case StdOutChannelType:
 		fallthrough
 	case MyTeamChannelType:
+		fallthrough
+	case SlackChannelType:
+		fallthrough
+	case TelegramChannelType:
+		fallthrough
+	case WebhookChannelType:
+		fallthrough
+	case SMTPChannelType:
 		return nil
 	}
 
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
+)
+
+const (
+	defaultWebhookMethod      = http.MethodPost
+	defaultWebhookContentType = "application/json"
+	defaultWebhookBody        = `{"text": {{json .Text}}}`
+	defaultWebhookTimeout     = 5 * time.Second
+)
+
+const (
+	cfgWebhookPrefix = "webhook"
+
+	cfgWebhookURLTitle         = cfgWebhookPrefix + ".url"
+	cfgWebhookMethodTitle      = cfgWebhookPrefix + ".method"
+	cfgWebhookHeadersTitle     = cfgWebhookPrefix + ".headers"
+	cfgWebhookContentTypeTitle = cfgWebhookPrefix + ".content_type"
+	cfgWebhookBodyTitle        = cfgWebhookPrefix + ".body"
+	cfgWebhookTimeoutTitle     = cfgWebhookPrefix + ".timeout"
+)
+
+// Config configures requests sent to an arbitrary URL on every update.
+// Body is a text/template executed with notifychannel.Notification.
+type Config struct {
+	prefix string
+
+	URL         string
+	Method      string
+	Headers     map[string]string
+	ContentType string `mapstructure:"content_type"`
+	Body        string
+	Timeout     time.Duration
+}
+
+func NewConfig(prefix string) Config {
+	config := Config{
+		prefix: prefix,
+	}
+
+	return config
+}
+
+func (cfg *Config) Validate() error {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	if len(cfg.URL) == 0 {
+		return fmt.Errorf(
+			"%s: %w", paramNameFactory(cfgWebhookURLTitle), cfgUtil.ErrMustNotBeEmpty,
+		)
+	}
+
+	if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf(
+			"%s: must be an http(s) url: %w", paramNameFactory(cfgWebhookURLTitle), cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	if len(cfg.Method) == 0 {
+		cfg.Method = defaultWebhookMethod
+	}
+
+	cfg.Method = strings.ToUpper(cfg.Method)
+
+	if len(cfg.ContentType) == 0 {
+		cfg.ContentType = defaultWebhookContentType
+	}
+	if len(cfg.Body) == 0 {
+		cfg.Body = defaultWebhookBody
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultWebhookTimeout
+	}
+
+	if _, err := newBodyTemplate(cfg.Body); err != nil {
+		return fmt.Errorf(
+			"%s: %v: %w", paramNameFactory(cfgWebhookBodyTitle), err, cfgUtil.ErrInvalidValue,
+		)
+	}
+
+	return nil
+}
+
+func (cfg Config) Print() {
+	paramNameFactory := cfg.paramWithPrefix()
+
+	// url and header values may contain secrets
+	log.Printf("%s: %s", paramNameFactory(cfgWebhookMethodTitle), cfg.Method)
+
+	for name := range cfg.Headers {
+		log.Printf("%s: %s: ***", paramNameFactory(cfgWebhookHeadersTitle), name)
+	}
+
+	log.Printf("%s: %s", paramNameFactory(cfgWebhookContentTypeTitle), cfg.ContentType)
+	log.Printf("%s: %s", paramNameFactory(cfgWebhookBodyTitle), cfg.Body)
+	log.Printf("%s: %s", paramNameFactory(cfgWebhookTimeoutTitle), cfg.Timeout)
+}
+
+func (cfg *Config) SetPrefix(prefix string) {
+	cfg.prefix = prefix
+}
+
+func (cfg Config) paramWithPrefix() func(param string) string {
+	return cfgUtil.ParamWithPrefix(cfg.prefix)
+}
@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+const (
+	maxErrorBodyLen = 1024
+)
+
+var (
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+)
+
+var templateFuncs = template.FuncMap{
+	// json quotes the value so that it can be safely put into a JSON body
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+
+		return string(b), err
+	},
+}
+
+func newBodyTemplate(text string) (*template.Template, error) {
+	return template.New("body").Funcs(templateFuncs).Parse(text)
+}
+
+// NotifyChannel sends every update as an HTTP request with the body rendered
+// from the configured template.
+type NotifyChannel struct {
+	cfg Config
+
+	body       *template.Template
+	httpClient *http.Client
+}
+
+func NewNotifyChannel(config Config) (*NotifyChannel, error) {
+	body, err := newBodyTemplate(config.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse body template: %w", err)
+	}
+
+	log.Printf("info: webhook: will send %s requests on updates", config.Method)
+
+	return &NotifyChannel{
+		cfg:  config,
+		body: body,
+		httpClient: &http.Client{
+			Timeout: config.Timeout,
+		},
+	}, nil
+}
+
+func (ch *NotifyChannel) Send(text string) error {
+	return ch.SendNotification(notifychannel.Notification{
+		Text: text,
+	})
+}
+
+// SendNotification renders the body template with the given notification
+// and sends it.
+func (ch *NotifyChannel) SendNotification(n notifychannel.Notification) error {
+	body := &bytes.Buffer{}
+
+	if err := ch.body.Execute(body, n); err != nil {
+		return fmt.Errorf("could not render body: %w", err)
+	}
+
+	req, err := http.NewRequest(ch.cfg.Method, ch.cfg.URL, body)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", ch.cfg.ContentType)
+
+	for name, value := range ch.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := ch.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLen)) // nolint: errcheck
+
+		return fmt.Errorf(
+			"%d '%s': %w", resp.StatusCode, strings.TrimSpace(string(respBody)), ErrUnexpectedStatus,
+		)
+	}
+
+	return nil
+}
+
+func (ch *NotifyChannel) Shutdown() error {
+	return nil
+}
@@ -0,0 +1,123 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+type request struct {
+	method string
+	header http.Header
+	body   string
+}
+
+func newTestServer(t *testing.T, status int) (*httptest.Server, chan request) {
+	requests := make(chan request, 16)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("could not read request body: %v", err)
+		}
+
+		requests <- request{method: r.Method, header: r.Header, body: string(body)}
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, requests
+}
+
+func newTestNotifyChannel(t *testing.T, config Config) *NotifyChannel {
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	ch, err := NewNotifyChannel(config)
+	if err != nil {
+		t.Fatalf("could not create notify channel: %v", err)
+	}
+
+	return ch
+}
+
+func TestNotifyChannelSend(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusOK)
+
+	config := NewConfig("test_project")
+	config.URL = srv.URL
+
+	ch := newTestNotifyChannel(t, config)
+
+	assert.NoError(t, ch.Send(`"test1" is on duty`))
+
+	req := <-requests
+	assert.Equal(t, http.MethodPost, req.method)
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, `{"text": "\"test1\" is on duty"}`, req.body)
+}
+
+func TestNotifyChannelSendNotification(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusNoContent)
+
+	config := NewConfig("test_project")
+	config.URL = srv.URL
+	config.Method = "put"
+	config.ContentType = "text/plain"
+	config.Headers = map[string]string{"x-api-key": "secret"}
+	config.Body = `{{.Project}}: {{.PreviousPerson}} -> {{.NewPerson}} till ` +
+		`{{.NextChange.Format "2006-01-02 15:04"}}`
+
+	ch := newTestNotifyChannel(t, config)
+
+	err := ch.SendNotification(notifychannel.Notification{
+		Project:        "test_project",
+		Text:           "test2 is on duty",
+		NewPerson:      "test2",
+		PreviousPerson: "test1",
+		NextChange:     time.Date(2022, time.March, 1, 10, 0, 0, 0, time.UTC),
+	})
+	assert.NoError(t, err)
+
+	req := <-requests
+	assert.Equal(t, http.MethodPut, req.method)
+	assert.Equal(t, "text/plain", req.header.Get("Content-Type"))
+	assert.Equal(t, "secret", req.header.Get("X-Api-Key"))
+	assert.Equal(t, "test_project: test1 -> test2 till 2022-03-01 10:00", req.body)
+}
+
+func TestNotifyChannelSendUnexpectedStatus(t *testing.T) {
+	srv, _ := newTestServer(t, http.StatusBadGateway)
+
+	config := NewConfig("test_project")
+	config.URL = srv.URL
+
+	ch := newTestNotifyChannel(t, config)
+
+	assert.ErrorIs(t, ch.Send("test1 is on duty"), ErrUnexpectedStatus)
+}
+
+func TestConfigValidate(t *testing.T) {
+	config := NewConfig("test_project")
+	assert.Error(t, config.Validate())
+
+	config.URL = "ftp://example.com"
+	assert.Error(t, config.Validate())
+
+	config.URL = "https://example.com/hook"
+	config.Body = "{{.NewPerson"
+	assert.Error(t, config.Validate())
+
+	config.Body = ""
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, defaultWebhookBody, config.Body)
+	assert.Equal(t, http.MethodPost, config.Method)
+}
@@ -0,0 +1,172 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+var (
+	ErrNotFound = errors.New("entry not found")
+)
+
+// Entry is a notification waiting to be delivered to a channel.
+type Entry struct {
+	ID           uint64                     `json:"id"`
+	Channel      string                     `json:"channel"`
+	Notification notifychannel.Notification `json:"notification"`
+	Created      time.Time                  `json:"created"`
+	Attempts     int                        `json:"attempts"` // failed attempts so far
+}
+
+// Outbox keeps notifications until they are delivered. If it is backed by a store,
+// every change is saved so that notifications survive restarts.
+type Outbox struct {
+	store Store // no persistence if nil
+
+	entries []Entry // in order of creation
+	nextID  uint64
+
+	mu sync.Mutex
+}
+
+// New creates an outbox backed by the given file, loading entries saved
+// earlier. The previous version of the file is loaded if the file can not be
+// read. In case neither can, the outbox starts empty and the error is returned
+// along with it. Empty path makes an in-memory outbox.
+func New(path string) (*Outbox, error) {
+	if len(path) == 0 {
+		return NewWithStore(nil)
+	}
+
+	return NewWithStore(NewFileStore(path))
+}
+
+// NewWithStore creates an outbox backed by the given store, loading entries
+// saved earlier. In case they can not be loaded, the outbox starts empty and
+// the error is returned along with it. Nil store makes an in-memory outbox.
+func NewWithStore(store Store) (*Outbox, error) {
+	o := &Outbox{
+		store:  store,
+		nextID: 1,
+	}
+
+	if store == nil {
+		return o, nil
+	}
+
+	entries, err := store.Load()
+	if err != nil {
+		return o, err
+	}
+
+	o.entries = entries
+
+	for _, e := range entries {
+		if e.ID >= o.nextID {
+			o.nextID = e.ID + 1
+		}
+	}
+
+	return o, nil
+}
+
+// Push adds a notification for the given channel. The entry is kept in memory
+// even if it could not be saved.
+func (o *Outbox) Push(channel string, n notifychannel.Notification) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.entries = append(o.entries, Entry{
+		ID:           o.nextID,
+		Channel:      channel,
+		Notification: n,
+		Created:      time.Now(),
+	})
+	o.nextID++
+
+	return o.save()
+}
+
+// Peek returns the oldest entry for the given channel.
+func (o *Outbox) Peek(channel string) (Entry, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, e := range o.entries {
+		if e.Channel == channel {
+			return e, true
+		}
+	}
+
+	return Entry{}, false
+}
+
+// Entries returns all entries in order of creation.
+func (o *Outbox) Entries() []Entry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]Entry(nil), o.entries...)
+}
+
+// Len returns the number of entries waiting for delivery.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return len(o.entries)
+}
+
+// Remove deletes the entry with the given id.
+func (o *Outbox) Remove(id uint64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	idx, err := o.find(id)
+	if err != nil {
+		return err
+	}
+
+	o.entries = append(o.entries[:idx], o.entries[idx+1:]...)
+
+	return o.save()
+}
+
+// MarkFailed counts a failed attempt to deliver the entry with the given id
+// and returns the number of failed attempts so far.
+func (o *Outbox) MarkFailed(id uint64) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	idx, err := o.find(id)
+	if err != nil {
+		return 0, err
+	}
+
+	o.entries[idx].Attempts++
+
+	return o.entries[idx].Attempts, o.save()
+}
+
+func (o *Outbox) find(id uint64) (int, error) {
+	for i, e := range o.entries {
+		if e.ID == id {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%d: %w", id, ErrNotFound)
+}
+
+// save writes all entries to the store, the store is never left half-written.
+func (o *Outbox) save() error {
+	if o.store == nil {
+		return nil
+	}
+
+	return o.store.Save(o.entries)
+}
@@ -0,0 +1,98 @@
+package outbox
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+)
+
+func TestOutbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test_project.outbox")
+
+	o, err := New(path)
+	if err != nil {
+		t.Fatalf("could not create outbox: %v", err)
+	}
+
+	nextChange := time.Date(2022, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, o.Push("myteam[0]", notifychannel.Notification{Text: "test1 is on duty"}))
+	assert.NoError(t, o.Push("smtp[1]", notifychannel.Notification{Text: "test1 is on duty"}))
+	assert.NoError(t, o.Push("myteam[0]", notifychannel.Notification{
+		Project: "test_project", Text: "test2 is on duty", NewPerson: "test2", NextChange: nextChange,
+	}))
+
+	e, ok := o.Peek("myteam[0]")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), e.ID)
+
+	attempts, err := o.MarkFailed(e.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+
+	assert.NoError(t, o.Remove(2))
+	assert.ErrorIs(t, o.Remove(2), ErrNotFound)
+
+	_, ok = o.Peek("smtp[1]")
+	assert.False(t, ok)
+
+	// entries survive restarts
+	restored, err := New(path)
+	if err != nil {
+		t.Fatalf("could not restore outbox: %v", err)
+	}
+
+	entries := restored.Entries()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, 1, entries[0].Attempts)
+		assert.Equal(t, "test2", entries[1].Notification.NewPerson)
+		assert.True(t, nextChange.Equal(entries[1].Notification.NextChange))
+	}
+
+	assert.NoError(t, restored.Push("smtp[1]", notifychannel.Notification{}))
+	assert.Equal(t, uint64(4), restored.Entries()[2].ID)
+
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2, "only the outbox and its backup must be left")
+}
+
+func TestOutboxCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test_project.outbox")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("[{"), 0600))
+
+	o, err := New(path)
+	assert.Error(t, err)
+
+	if assert.NotNil(t, o) {
+		assert.Equal(t, 0, o.Len())
+		assert.NoError(t, o.Push("stdout[0]", notifychannel.Notification{}))
+	}
+}
+
+func TestOutboxBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test_project.outbox")
+
+	o, err := New(path)
+	if err != nil {
+		t.Fatalf("could not create outbox: %v", err)
+	}
+
+	assert.NoError(t, o.Push("stdout[0]", notifychannel.Notification{Text: "test1 is on duty"}))
+	assert.NoError(t, o.Push("stdout[0]", notifychannel.Notification{Text: "test2 is on duty"}))
+
+	// the last write has been lost, the previous version is restored
+	assert.NoError(t, ioutil.WriteFile(path, []byte("[{"), 0600))
+
+	restored, err := New(path)
+	assert.NoError(t, err)
+
+	if entries := restored.Entries(); assert.Len(t, entries, 1) {
+		assert.Equal(t, "test1 is on duty", entries[0].Notification.Text)
+	}
+}
@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/gibsn/duty_bot/internal/atomicfile"
+)
+
+// Store keeps the entries of an outbox between restarts.
+type Store interface {
+	Load() ([]Entry, error) // no entries and no error if nothing has been saved yet
+	Save(entries []Entry) error
+}
+
+// fileStore keeps entries in a file as a JSON array, the previous version of
+// the file is kept as a backup.
+type fileStore struct {
+	path string
+}
+
+// NewFileStore creates a store keeping entries in the given file.
+func NewFileStore(path string) Store {
+	return fileStore{path: path}
+}
+
+// Load reads the entries from the file or, if it can not be read, from the
+// previous version of the file.
+func (s fileStore) Load() ([]Entry, error) {
+	entries, err := s.load(s.path)
+	if err == nil {
+		return entries, nil
+	}
+
+	entries, backupErr := s.load(atomicfile.BackupPath(s.path))
+
+	switch {
+	case backupErr == nil:
+		log.Printf("warning: outbox: restoring previous version of '%s': %v", s.path, err)
+		return entries, nil
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	}
+
+	return nil, err
+}
+
+func (s fileStore) load(path string) ([]Entry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read outbox: %w", err)
+	}
+
+	var entries []Entry
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("could not parse outbox '%s': %w", path, err)
+	}
+
+	return entries, nil
+}
+
+// Save replaces the file so that it is never left half-written.
+func (s fileStore) Save(entries []Entry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("could not marshal outbox: %w", err)
+	}
+
+	if err := atomicfile.Write(s.path, data, true); err != nil {
+		return fmt.Errorf("could not save outbox: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package productioncal
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/anatoliyfedorenko/isdayoff"
+)
+
+// Archive is the production calendar for a fixed range of dates. Unlike
+// ProductionCal it is fetched once a month per request and suits the past
+// dates, which makes it useful for reports.
+type Archive struct {
+	country    string
+	daysCaches map[string]*DayOffsCache // country code -> days of the range
+}
+
+// FetchArchive fetches the calendars of the default country of the config and
+// of the given ones for every month covering [from, till).
+func FetchArchive(cfg Config, from, till time.Time, countries ...string) (*Archive, error) {
+	a := &Archive{
+		country:    cfg.Country,
+		daysCaches: make(map[string]*DayOffsCache, len(countries)+1),
+	}
+
+	client := isdayoff.NewWithClient(&http.Client{Timeout: cfg.APITimeout})
+
+	for _, country := range append([]string{cfg.Country}, countries...) {
+		if _, ok := a.daysCaches[country]; ok {
+			continue
+		}
+
+		days, err := fetchMonths(client, country, from, till)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch calendar of '%s': %w", country, err)
+		}
+
+		a.daysCaches[country] = NewDayOffsCache()
+		a.daysCaches[country].Set(days)
+	}
+
+	return a, nil
+}
+
+// fetchMonths fetches the day offs of the whole months covering [from, till)
+func fetchMonths(
+	client *isdayoff.Client, country string, from, till time.Time,
+) (map[date]bool, error) {
+	countryCode := isdayoff.CountryCode(country)
+	days := make(map[date]bool)
+
+	year, month, _ := from.Date()
+
+	for first := time.Date(year, month, 1, 0, 0, 0, 0, from.Location()); first.Before(till); {
+		currYear, currMonth, _ := first.Date()
+
+		params := isdayoff.Params{
+			Year:        currYear,
+			Month:       &currMonth,
+			CountryCode: &countryCode,
+		}
+
+		res, err := client.GetBy(params)
+		if err != nil {
+			return nil, fmt.Errorf("request [%+v] failed: %w", params, err)
+		}
+
+		next := first.AddDate(0, 1, 0)
+
+		if n := next.AddDate(0, 0, -1).Day(); len(res) != n {
+			return nil, fmt.Errorf(
+				"request [%+v] returned %d days instead of %d", params, len(res), n,
+			)
+		}
+
+		for i, dayType := range res {
+			days[date{year: currYear, month: currMonth, day: i + 1}] =
+				dayType == isdayoff.DayTypeNonWorking
+		}
+
+		first = next
+	}
+
+	return days, nil
+}
+
+// IsDayOff checks if the given day is a day off according to the default calendar.
+// If the given date is out of the range of the archive, an error is returned.
+func (a *Archive) IsDayOff(t time.Time) (bool, error) {
+	return a.IsDayOffIn(a.country, t)
+}
+
+// IsDayOffIn is the same as IsDayOff but checks the calendar of the given country,
+// the calendar must have been requested when fetching.
+func (a *Archive) IsDayOffIn(country string, t time.Time) (bool, error) {
+	daysCache, ok := a.daysCaches[country]
+	if !ok {
+		return false, fmt.Errorf("country '%s': %w", country, ErrCountryNotFound)
+	}
+
+	return daysCache.IsDayOff(newDateFromTime(t))
+}
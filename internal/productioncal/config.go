@@ -1,13 +1,20 @@
 package productioncal
 
 import (
+	"fmt"
 	"log"
 	"time"
+
+	"github.com/anatoliyfedorenko/isdayoff"
+
+	cfgUtil "github.com/gibsn/duty_bot/internal/cfg"
 )
 
 type Config struct {
 	Enabled bool
 
+	Country string // isdayoff.ru country code of the default calendar
+
 	CacheInterval uint          `mapstructure:"cache_interval"`
 	RecachePeriod time.Duration `mapstructure:"recache_period"`
 
@@ -16,6 +23,7 @@ type Config struct {
 }
 
 const (
+	defaultCountry       = isdayoff.CountryCodeRussia
 	defaultCacheInterval = 7
 	defaultRecachePeriod = 24 * time.Hour
 	// defaultAPIHost       = "https://isdayoff.ru"
@@ -26,6 +34,7 @@ const (
 	cfgProductionCalPrefix = "production_cal"
 
 	cfgProductionCalEnabledTitle       = cfgProductionCalPrefix + ".enabled"
+	cfgProductionCalCountryTitle       = cfgProductionCalPrefix + ".country"
 	cfgProductionCalCacheIntervalTitle = cfgProductionCalPrefix + ".cache_interval"
 	cfgProductionCalRecachePeriodTitle = cfgProductionCalPrefix + ".recache_period"
 	// cfgProductionCalAPIHostTitle       = cfgProductionCalPrefix + ".host"
@@ -39,6 +48,12 @@ func NewConfig() *Config {
 }
 
 func (c *Config) Validate() error {
+	if len(c.Country) == 0 {
+		c.Country = string(defaultCountry)
+	}
+	if err := ValidateCountry(c.Country); err != nil {
+		return fmt.Errorf("%s: %w", cfgProductionCalCountryTitle, err)
+	}
 	if c.CacheInterval == 0 {
 		c.CacheInterval = defaultCacheInterval
 	}
@@ -54,8 +69,21 @@ func (c *Config) Validate() error {
 
 func (c *Config) Print() {
 	log.Print(cfgProductionCalEnabledTitle+": ", c.Enabled)
+	log.Print(cfgProductionCalCountryTitle+": ", c.Country)
 	log.Print(cfgProductionCalCacheIntervalTitle+": ", c.CacheInterval)
 	log.Print(cfgProductionCalRecachePeriodTitle+": ", c.RecachePeriod)
 	// log.Print(cfgProductionCalAPIHostTitle+": ", *c.APIHost)
 	log.Print(cfgProductionCalAPITimeoutTitle+": ", c.APITimeout)
 }
+
+// ValidateCountry checks that isdayoff.ru has a calendar for the given country code
+func ValidateCountry(country string) error {
+	switch isdayoff.CountryCode(country) {
+	case isdayoff.CountryCodeBelarus, isdayoff.CountryCodeKazakhstan, isdayoff.CountryCodeRussia,
+		isdayoff.CountryCodeUkraine, isdayoff.CountryCodeUSA, isdayoff.CountryCodeUzbekistan,
+		isdayoff.CountryCodeTurkey:
+		return nil
+	}
+
+	return fmt.Errorf("country '%s': %w", country, cfgUtil.ErrNotSupported)
+}
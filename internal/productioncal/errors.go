@@ -5,5 +5,6 @@ import (
 )
 
 var (
-	ErrDateNotFound = errors.New("date not found")
+	ErrDateNotFound    = errors.New("date not found")
+	ErrCountryNotFound = errors.New("country not found")
 )
@@ -12,33 +12,45 @@ import (
 // ProductionCal can answer whether the given date is a day off according to
 // https://isdayoff.ru. It contains a local cache for CacheInterval days starting
 // today. If the given date is not present in cache an error is returned. Cache is
-// refetched every RecachePeriod.
+// refetched every RecachePeriod. Besides the default country of the config,
+// calendars of other countries may be requested.
 type ProductionCal struct {
 	cfg Config
 
-	daysCache *DayOffsCache
+	daysCaches map[string]*DayOffsCache // country code -> cache
 
 	httpClient  http.Client
 	isDayOffAPI *isdayoff.Client
 }
 
-// NewProductionCal is a constructor for ProductionCal
-func NewProductionCal(cfg Config) *ProductionCal {
+// NewProductionCal is a constructor for ProductionCal, the calendars of the
+// given countries are fetched along with the default one
+func NewProductionCal(cfg Config, countries ...string) *ProductionCal {
 	cal := &ProductionCal{
-		cfg:       cfg,
-		daysCache: NewDayOffsCache(),
+		cfg: cfg,
+		daysCaches: map[string]*DayOffsCache{
+			cfg.Country: NewDayOffsCache(),
+		},
 		httpClient: http.Client{
 			Timeout: cfg.APITimeout,
 		},
 	}
 
+	for _, country := range countries {
+		if _, ok := cal.daysCaches[country]; !ok {
+			cal.daysCaches[country] = NewDayOffsCache()
+		}
+	}
+
 	cal.isDayOffAPI = isdayoff.NewWithClient(&cal.httpClient)
 
 	return cal
 }
 
-func (cal *ProductionCal) fetchDayOffs(today time.Time, days uint) (map[date]bool, error) {
-	var countryCode = isdayoff.CountryCodeRussia
+func (cal *ProductionCal) fetchDayOffs(
+	country string, today time.Time, days uint,
+) (map[date]bool, error) {
+	var countryCode = isdayoff.CountryCode(country)
 
 	cache := make(map[date]bool, days)
 
@@ -65,18 +77,21 @@ func (cal *ProductionCal) fetchDayOffs(today time.Time, days uint) (map[date]boo
 	return cache, nil
 }
 
-// Init populates the cache for the first time synchronously
+// Init populates the caches for the first time synchronously
 func (cal *ProductionCal) Init() error {
-	newCache, err := cal.fetchDayOffs(time.Now(), cal.cfg.CacheInterval)
-	if err != nil {
-		return fmt.Errorf("could not initialise day offs cache: %w", err)
-	}
+	for country, daysCache := range cal.daysCaches {
+		newCache, err := cal.fetchDayOffs(country, time.Now(), cal.cfg.CacheInterval)
+		if err != nil {
+			return fmt.Errorf("could not initialise day offs cache of '%s': %w", country, err)
+		}
 
-	cal.daysCache.Set(newCache)
+		daysCache.Set(newCache)
 
-	log.Printf(
-		"info: productioncal: day offs cache has been successfully fetched: [%s]", cal.daysCache,
-	)
+		log.Printf(
+			"info: productioncal: day offs cache of '%s' has been successfully fetched: [%s]",
+			country, daysCache,
+		)
+	}
 
 	return nil
 }
@@ -89,19 +104,23 @@ func (cal *ProductionCal) Routine() {
 
 		log.Println("info: productioncal: will refetch day offs cache")
 
-		newCache, err := cal.fetchDayOffs(time.Now(), cal.cfg.CacheInterval)
-		if err != nil {
-			log.Printf("error: productioncal: could not refetch day offs cache: %v", err)
-			log.Printf("warning: productioncal: will use the old cache until next refetch")
-			continue
+		for country, daysCache := range cal.daysCaches {
+			newCache, err := cal.fetchDayOffs(country, time.Now(), cal.cfg.CacheInterval)
+			if err != nil {
+				log.Printf(
+					"error: productioncal: could not refetch day offs cache of '%s': %v", country, err,
+				)
+				log.Printf("warning: productioncal: will use the old cache until next refetch")
+				continue
+			}
+
+			daysCache.Set(newCache)
+
+			log.Printf(
+				"info: productioncal: day offs cache of '%s' has been successfully fetched: [%s]",
+				country, daysCache,
+			)
 		}
-
-		cal.daysCache.Set(newCache)
-
-		log.Printf(
-			"info: productioncal: day offs cache has been successfully fetched: [%s]",
-			cal.daysCache,
-		)
 	}
 }
 
@@ -109,5 +128,16 @@ func (cal *ProductionCal) Routine() {
 // production calendar cache. If the given date is not present in
 // cache, errors is returned.
 func (cal *ProductionCal) IsDayOff(t time.Time) (bool, error) {
-	return cal.daysCache.IsDayOff(newDateFromTime(t))
+	return cal.IsDayOffIn(cal.cfg.Country, t)
+}
+
+// IsDayOffIn is the same as IsDayOff but checks the calendar of the given country,
+// the calendar must have been requested in the constructor.
+func (cal *ProductionCal) IsDayOffIn(country string, t time.Time) (bool, error) {
+	daysCache, ok := cal.daysCaches[country]
+	if !ok {
+		return false, fmt.Errorf("country '%s': %w", country, ErrCountryNotFound)
+	}
+
+	return daysCache.IsDayOff(newDateFromTime(t))
 }
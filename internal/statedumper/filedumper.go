@@ -5,58 +5,139 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+
+	"github.com/gibsn/duty_bot/internal/atomicfile"
 )
 
 const (
 	dumperQueueCap = 16
+
+	defaultStateDir = "."
+	stateDirPerm    = 0o755
 )
 
 // FileDumper is an implementation of a StateDumper that uses
 // simple files on disk.
 type FileDumper struct {
+	dir    string
 	dumpQ  chan Dumpable
 	states map[string]SchedulingState
 	wg     sync.WaitGroup
+
+	historyMu sync.Mutex // serialises appends to history files
 }
 
-// NewFileDumper creates a new FileDumper, parsing all data on disk
-// for a faster future access.
-func NewFileDumper() (*FileDumper, error) {
+// NewFileDumper creates a new FileDumper keeping states in the given directory
+// (the working one if empty), parsing all data on disk for a faster future access.
+// States that can not be read are restored from their previous versions if possible,
+// otherwise they are skipped so that the other projects are not affected.
+func NewFileDumper(dir string) (*FileDumper, error) {
+	if len(dir) == 0 {
+		dir = defaultStateDir
+	}
+
 	fd := &FileDumper{
+		dir:    dir,
 		dumpQ:  make(chan Dumpable, dumperQueueCap),
 		states: make(map[string]SchedulingState),
 	}
 
-	fileInfos, err := ioutil.ReadDir("./")
+	if err := os.MkdirAll(dir, stateDirPerm); err != nil {
+		return nil, fmt.Errorf("could not create dir: %w", err)
+	}
+
+	states, err := LoadStateFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fd.states = states
+
+	fd.wg.Add(1)
+	go fd.stateSaverRoutine()
+
+	return fd, nil
+}
+
+// Dir returns the directory states are kept in.
+func (fd *FileDumper) Dir() string {
+	return fd.dir
+}
+
+// LoadStateFiles reads all the state files in the given directory. States that
+// can not be read are restored from their previous versions if possible, otherwise
+// they are skipped.
+func LoadStateFiles(dir string) (map[string]SchedulingState, error) {
+	if len(dir) == 0 {
+		dir = defaultStateDir
+	}
+
+	fileInfos, err := ioutil.ReadDir(dir)
 	if err != nil {
-		return nil, fmt.Errorf("could not read dir: %v", err)
+		return nil, fmt.Errorf("could not read dir: %w", err)
 	}
 
+	// a state may be left as a backup only if the bot has crashed while saving it
+	fileNames := make(map[string]bool)
+
 	for _, fileInfo := range fileInfos {
-		fileName := fileInfo.Name()
+		fileName := strings.TrimSuffix(fileInfo.Name(), atomicfile.BackupSuffix)
 
-		if !IsStateFile(fileName) {
-			continue
+		if IsStateFile(fileName) {
+			fileNames[fileName] = true
 		}
+	}
 
-		file, err := os.Open(fileName)
-		if err != nil {
-			return nil, fmt.Errorf("could not read file '%s': %v", fileName, err)
-		}
+	states := make(map[string]SchedulingState, len(fileNames))
 
-		state, err := NewSchedulingState(file)
+	for fileName := range fileNames {
+		state, err := loadState(filepath.Join(dir, fileName))
 		if err != nil {
-			return nil, fmt.Errorf("could not read state from file '%s': %v", fileName, err)
+			log.Printf("error: filedumper: skipping '%s': %v", fileName, err)
+			continue
 		}
 
-		fd.states[state.Name] = state
+		states[state.Name] = state
 	}
 
-	fd.wg.Add(1)
-	go fd.stateSaverRoutine()
+	return states, nil
+}
 
-	return fd, nil
+// loadState reads the state from the given file or, if it fails, from
+// the previous version of the file.
+func loadState(path string) (SchedulingState, error) {
+	state, err := readStateFile(path)
+	if err == nil {
+		return state, nil
+	}
+
+	state, backupErr := readStateFile(atomicfile.BackupPath(path))
+	if backupErr != nil {
+		return SchedulingState{}, err
+	}
+
+	log.Printf("warning: filedumper: restoring previous version of '%s': %v", path, err)
+
+	return state, nil
+}
+
+func readStateFile(path string) (SchedulingState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return SchedulingState{}, fmt.Errorf("could not read file: %w", err)
+	}
+
+	defer file.Close()
+
+	state, err := NewSchedulingState(file)
+	if err != nil {
+		return SchedulingState{}, fmt.Errorf("could not read state from file '%s': %w", path, err)
+	}
+
+	return state, nil
 }
 
 // Dump writes the given project state in async way. Calling Dump after Shutdown
@@ -89,7 +170,7 @@ func (fd *FileDumper) stateSaverRoutine() {
 	for p := range fd.dumpQ {
 		if err := fd.stateSaverRoutineImpl(p); err != nil {
 			log.Printf("error: [%s] could not dump state to disk, scheduling will start "+
-				"from beginning in case of restart", p.Name(),
+				"from beginning in case of restart: %v", p.Name(), err,
 			)
 			continue
 		}
@@ -98,20 +179,19 @@ func (fd *FileDumper) stateSaverRoutine() {
 	}
 }
 
-func (fd *FileDumper) stateSaverRoutineImpl(state Dumpable) (err error) {
-	file, err := os.Create(state.Name() + ".state")
-	if err != nil {
-		return fmt.Errorf("could not create file: %w", err)
+// stateSaverRoutineImpl replaces the state file so that it is never left
+// half-written, the previous version is kept as a backup
+func (fd *FileDumper) stateSaverRoutineImpl(state Dumpable) error {
+	buf := &strings.Builder{}
+
+	if err := state.DumpState(buf); err != nil {
+		return err
 	}
 
-	defer func() {
-		if err = file.Close(); err != nil {
-			err = fmt.Errorf("could not close file: %w", err)
-		}
-	}()
+	path := filepath.Join(fd.dir, state.Name()+diskSuffix)
 
-	if err = state.DumpState(file); err != nil {
-		return err
+	if err := atomicfile.Write(path, []byte(buf.String()), true); err != nil {
+		return fmt.Errorf("could not save state: %w", err)
 	}
 
 	return nil
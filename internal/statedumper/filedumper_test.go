@@ -0,0 +1,72 @@
+package statedumper
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dumpableMock struct {
+	name  string
+	state string
+}
+
+func (d dumpableMock) DumpState(w io.StringWriter) error {
+	_, err := w.WriteString(d.state)
+	return err
+}
+
+func (d dumpableMock) Name() string {
+	return d.name
+}
+
+func TestFileDumper(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "states")
+
+	fd, err := NewFileDumper(dir)
+	if err != nil {
+		t.Fatalf("could not create file dumper: %v", err)
+	}
+
+	assert.NoError(t, fd.Dump(dumpableMock{"test1", "test1\n0\n1609074301\n"}))
+	assert.NoError(t, fd.Dump(dumpableMock{"test1", "test1\n1\n1609074302\n"}))
+	assert.NoError(t, fd.Dump(dumpableMock{"test2", "test2\n0\n1609074301\n"}))
+	assert.NoError(t, fd.Dump(dumpableMock{"test3", "test3\n0\n1609074301\n"}))
+	assert.NoError(t, fd.Dump(dumpableMock{"test3", "test3\n1\n1609074302\n"}))
+	fd.Shutdown()
+
+	write := func(name, data string) {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0600))
+	}
+
+	// test1 has been corrupted, test2 is corrupted without a previous version, test4 has been
+	// lost while being saved the first time
+	write("test1.state", "test1\nx")
+	write("test2.state", "")
+	write("test4.state.bak", "test4\n1\n1609074301\n")
+
+	fd, err = NewFileDumper(dir)
+	if err != nil {
+		t.Fatalf("could not create file dumper: %v", err)
+	}
+
+	defer fd.Shutdown()
+
+	state, err := fd.GetState("test1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), state.CurrentPerson)
+
+	_, err = fd.GetState("test2")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	state, err = fd.GetState("test3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), state.CurrentPerson)
+
+	state, err = fd.GetState("test4")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), state.CurrentPerson)
+}
@@ -0,0 +1,176 @@
+package statedumper
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// HistoryFileExt is the extension of files keeping duty history of the projects
+	HistoryFileExt = ".history"
+
+	historyFilePerm = 0o644
+)
+
+// HistoryReason explains why a history record has been made
+type HistoryReason string
+
+const (
+	// ReasonScheduled means the person took duty in their turn
+	ReasonScheduled HistoryReason = "scheduled"
+	// ReasonSkippedForVacation means the turn of the person was passed since
+	// they were on vacation
+	ReasonSkippedForVacation HistoryReason = "skipped_for_vacation"
+	// ReasonSwap means the person took duty due to a swap or a hand-off
+	ReasonSwap HistoryReason = "swap"
+	// ReasonForced means the person took duty due to a forced change
+	ReasonForced HistoryReason = "forced"
+)
+
+// HistoryRecord is an entry of the append-only duty history of a project
+type HistoryRecord struct {
+	Time   time.Time     `json:"time"`
+	Person string        `json:"person"`
+	Reason HistoryReason `json:"reason"`
+	Region string        `json:"region,omitempty"`
+}
+
+// TakesDuty reports whether the person has taken duty according to the record
+func (r HistoryRecord) TakesDuty() bool {
+	return r.Reason != ReasonSkippedForVacation
+}
+
+// inRange reports whether the record has been made in [from, till),
+// zero bounds are not checked
+func (r HistoryRecord) inRange(from, till time.Time) bool {
+	if !from.IsZero() && r.Time.Before(from) {
+		return false
+	}
+
+	return till.IsZero() || r.Time.Before(till)
+}
+
+// AppendHistory appends the given records to the history of the project synchronously.
+func (fd *FileDumper) AppendHistory(project string, records ...HistoryRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	fd.historyMu.Lock()
+	defer fd.historyMu.Unlock()
+
+	return AppendHistoryFile(filepath.Join(fd.dir, project+HistoryFileExt), records...)
+}
+
+// History returns the records of the history of the project made in [from, till)
+// in chronological order, zero bounds are not checked.
+func (fd *FileDumper) History(project string, from, till time.Time) ([]HistoryRecord, error) {
+	fd.historyMu.Lock()
+	defer fd.historyMu.Unlock()
+
+	records, err := ReadHistoryFile(filepath.Join(fd.dir, project+HistoryFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	return filterHistory(records, from, till), nil
+}
+
+// AppendHistoryFile appends the given records to the history file as JSON lines,
+// the file is synced to disk before returning.
+func AppendHistoryFile(path string, records ...HistoryRecord) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, historyFilePerm)
+	if err != nil {
+		return fmt.Errorf("could not open history: %w", err)
+	}
+
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("could not write history: %w", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("could not write history: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("could not sync history: %w", err)
+	}
+
+	return nil
+}
+
+// ReadHistoryFile reads all the records of the history file, a missing file
+// is an empty history. Lines that can not be parsed (for example, the last one
+// if the bot has crashed while writing it) are skipped.
+func ReadHistoryFile(path string) ([]HistoryRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open history: %w", err)
+	}
+
+	defer f.Close()
+
+	return readHistory(f, path)
+}
+
+func readHistory(r io.Reader, path string) ([]HistoryRecord, error) {
+	var records []HistoryRecord
+
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record HistoryRecord
+
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Printf("warning: filedumper: skipping line %d of '%s': %v", line, path, err)
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read history: %w", err)
+	}
+
+	return records, nil
+}
+
+// filterHistory returns the records made in [from, till) in chronological order
+func filterHistory(records []HistoryRecord, from, till time.Time) []HistoryRecord {
+	filtered := make([]HistoryRecord, 0, len(records))
+
+	for _, r := range records {
+		if r.inRange(from, till) {
+			filtered = append(filtered, r)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Time.Before(filtered[j].Time)
+	})
+
+	return filtered
+}
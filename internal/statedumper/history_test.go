@@ -0,0 +1,89 @@
+package statedumper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type historyStorage interface {
+	AppendHistory(project string, records ...HistoryRecord) error
+	History(project string, from, till time.Time) ([]HistoryRecord, error)
+}
+
+func testHistory(t *testing.T, s historyStorage) {
+	monday := time.Date(2024, time.January, 8, 10, 0, 0, 0, time.Local)
+
+	records := []HistoryRecord{
+		{Time: monday, Person: "test1", Reason: ReasonScheduled},
+		{Time: monday.Add(24 * time.Hour), Person: "test2", Reason: ReasonSkippedForVacation},
+		{Time: monday.Add(24 * time.Hour), Person: "test3", Reason: ReasonScheduled, Region: "eu"},
+	}
+
+	assert.NoError(t, s.AppendHistory("test_project", records[:1]...))
+	assert.NoError(t, s.AppendHistory("test_project", records[1:]...))
+	assert.NoError(t, s.AppendHistory("other_project", records[0]))
+
+	history, err := s.History("test_project", time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assertHistory(t, records, history)
+
+	history, err = s.History("test_project", monday.Add(time.Hour), time.Time{})
+	assert.NoError(t, err)
+	assertHistory(t, records[1:], history)
+
+	history, err = s.History("test_project", monday, monday.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assertHistory(t, records[:1], history)
+
+	history, err = s.History("unknown", time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func assertHistory(t *testing.T, expected, actual []HistoryRecord) {
+	t.Helper()
+
+	if !assert.Len(t, actual, len(expected)) {
+		return
+	}
+
+	for i := range expected {
+		assert.True(t, expected[i].Time.Equal(actual[i].Time), "record %d", i)
+
+		actual[i].Time = expected[i].Time
+		assert.Equal(t, expected[i], actual[i])
+	}
+}
+
+func TestFileDumperHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	fd, err := NewFileDumper(dir)
+	if err != nil {
+		t.Fatalf("could not create file dumper: %v", err)
+	}
+
+	defer fd.Shutdown()
+
+	testHistory(t, fd)
+
+	// a line left half-written by a crash is skipped
+	f, err := os.OpenFile(
+		filepath.Join(dir, "test_project"+HistoryFileExt), os.O_WRONLY|os.O_APPEND, 0,
+	)
+	if err != nil {
+		t.Fatalf("could not open history: %v", err)
+	}
+
+	_, err = f.WriteString(`{"time":"2024-01-10T10:00:00Z","per`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	history, err := fd.History("test_project", time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+}
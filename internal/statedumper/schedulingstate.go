@@ -2,22 +2,55 @@ package statedumper
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"log"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	// SchemaVersion is the version of the state format written by FormatState
+	SchemaVersion = 1
+
+	// the line format used before the states had versions
+	legacyVersion = 0
+)
+
+// fields of the legacy line format
 const (
 	fieldNameIdx           = 0
 	fieldCurrentPersonIdx  = 1
 	fieldTSOfLastChangeIdx = 2
+	fieldSubstituteIdx     = 3  // optional
+	fieldSwapsIdx          = 4  // optional
+	fieldSkipsIdx          = 5  // optional
+	fieldPausedIdx         = 6  // optional
+	fieldRolesIdx          = 7  // optional
+	fieldRegionIdx         = 8  // optional
+	fieldRegionsIdx        = 9  // optional
+	fieldTotalsIdx         = 10 // optional
+	fieldCountedTillIdx    = 11 // optional
+	fieldLastDutyIdx       = 12 // optional
+	fieldStreakIdx         = 13 // optional
+	fieldCurrentNameIdx    = 14 // optional
+	fieldRotationIdx       = 15 // optional
+	fieldRegionRotationIdx = 16 // optional
 )
 
 const (
 	diskSuffix = ".state"
+
+	swapsSeparator    = ","
+	swapPairSeparator = "="
+	totalsSeparator   = ":"
+	rotationSeparator = "|"
 )
 
 var (
@@ -26,15 +59,164 @@ var (
 
 var (
 	ErrInsufficientStateFile = errors.New("insufficient state file")
+	ErrInvalidSwaps          = errors.New("invalid swaps")
+	ErrInvalidRoles          = errors.New("invalid roles")
+	ErrInvalidPositions      = errors.New("invalid positions")
+	ErrInvalidTotals         = errors.New("invalid totals")
+	ErrInvalidTimes          = errors.New("invalid times")
+	ErrInvalidRotations      = errors.New("invalid rotations")
+	ErrUnsupportedVersion    = errors.New("unsupported version")
 )
 
 type SchedulingState struct {
-	Name             string
-	CurrentPerson    uint64
-	TimeOfLastChange time.Time
+	Version int `json:"version"` // version of the format the state was saved in
+
+	Name             string    `json:"name"`
+	CurrentPerson    uint64    `json:"current_person"`
+	TimeOfLastChange time.Time `json:"time_of_last_change"`
+
+	// person that took duty instead of the current one
+	Substitute string `json:"substitute,omitempty"`
+	// person -> who takes their next turn
+	Swaps map[string]string `json:"swaps,omitempty"`
+	// persons whose next turn is skipped
+	Skips  []string `json:"skips,omitempty"`
+	Paused bool     `json:"paused,omitempty"`
+
+	// role -> person holding it, the primary role is not included
+	Roles map[string]string `json:"roles,omitempty"`
+
+	// region on duty in follow-the-sun projects
+	Region string `json:"region,omitempty"`
+	// region -> position of its rotation
+	RegionPositions map[string]uint64 `json:"region_positions,omitempty"`
+
+	// applicant -> time on duty
+	Totals map[string]DutyTotals `json:"totals,omitempty"`
+	// time on duty is counted in totals till this time
+	CountedTill time.Time `json:"counted_till"`
+
+	// applicant -> end of their last shift
+	LastDuty map[string]time.Time `json:"last_duty,omitempty"`
+	// number of shifts in a row of the current person
+	Streak int `json:"streak,omitempty"`
+
+	// person at the current position of the rotation, empty if the rotation has not
+	// started, CurrentPerson is the position in Rotation then
+	CurrentName string `json:"current_name,omitempty"`
+	// applicants in the order they take duty
+	Rotation []string `json:"rotation,omitempty"`
+	// region -> applicants in the order they take duty
+	RegionRotations map[string][]string `json:"region_rotations,omitempty"`
+
+	// reminder -> start of the last shift an upcoming reminder has been sent for
+	// or the last time a daily reminder has been checked
+	RemindedOf map[string]time.Time `json:"reminded_of,omitempty"`
+}
+
+// DutyTotals is the time an applicant has been on duty
+type DutyTotals struct {
+	Duty    time.Duration // all the time on duty
+	DayOffs time.Duration // time on duty at day offs
+}
+
+// dutyTotalsJSON is DutyTotals as saved in the state, durations are given in seconds
+type dutyTotalsJSON struct {
+	Duty    int64 `json:"duty"`
+	DayOffs int64 `json:"day_offs"`
+}
+
+func (t DutyTotals) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dutyTotalsJSON{
+		Duty:    int64(t.Duty.Seconds()),
+		DayOffs: int64(t.DayOffs.Seconds()),
+	})
+}
+
+func (t *DutyTotals) UnmarshalJSON(data []byte) error {
+	var totals dutyTotalsJSON
+
+	if err := json.Unmarshal(data, &totals); err != nil {
+		return err
+	}
+
+	t.Duty = time.Duration(totals.Duty) * time.Second
+	t.DayOffs = time.Duration(totals.DayOffs) * time.Second
+
+	return nil
 }
 
+// migrations[i] converts a state of version i to version i+1
+var migrations = []func(state *SchedulingState){
+	legacyVersion: func(state *SchedulingState) {}, // the line format holds the same fields
+}
+
+// NewSchedulingState reads a state saved in any of the known formats, states saved
+// in the older ones are migrated to the current version.
 func NewSchedulingState(r io.Reader) (SchedulingState, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return SchedulingState{}, fmt.Errorf("invalid state file: %w", err)
+	}
+
+	var state SchedulingState
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) != 0 && trimmed[0] == '{' {
+		state, err = parseState(data)
+	} else {
+		state, err = parseLegacyState(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return SchedulingState{}, err
+	}
+
+	if state.Version < SchemaVersion {
+		log.Printf(
+			"info: statedumper: [%s] migrating state from version %d to %d",
+			state.Name, state.Version, SchemaVersion,
+		)
+	}
+
+	for ; state.Version < SchemaVersion; state.Version++ {
+		migrations[state.Version](&state)
+	}
+
+	return state, nil
+}
+
+// FormatState serialises the state in the current format.
+func FormatState(state SchedulingState) (string, error) {
+	state.Version = SchemaVersion
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("could not marshal state: %w", err)
+	}
+
+	return string(data) + "\n", nil
+}
+
+func parseState(data []byte) (SchedulingState, error) {
+	var state SchedulingState
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return SchedulingState{}, fmt.Errorf("invalid state file: %w", err)
+	}
+
+	if state.Version <= legacyVersion || state.Version > SchemaVersion {
+		return SchedulingState{}, fmt.Errorf("version %d: %w", state.Version, ErrUnsupportedVersion)
+	}
+
+	if len(state.Name) == 0 {
+		return SchedulingState{}, ErrInsufficientStateFile
+	}
+
+	return state, nil
+}
+
+// parseLegacyState parses a state saved in the line format, one field per line
+func parseLegacyState(r io.Reader) (SchedulingState, error) {
 	scanner := bufio.NewScanner(r)
 	linesParsed := 0
 	newState := SchedulingState{}
@@ -65,6 +247,111 @@ func NewSchedulingState(r io.Reader) (SchedulingState, error) {
 			}
 
 			newState.TimeOfLastChange = time.Unix(int64(ts), 0)
+
+		case fieldSubstituteIdx:
+			newState.Substitute = currLine
+
+		case fieldSwapsIdx:
+			swaps, err := ParseSwaps(currLine)
+			if err != nil {
+				return SchedulingState{}, fmt.Errorf("invalid swaps '%s': %w", currLine, err)
+			}
+
+			newState.Swaps = swaps
+
+		case fieldSkipsIdx:
+			if len(currLine) != 0 {
+				newState.Skips = strings.Split(currLine, swapsSeparator)
+			}
+
+		case fieldPausedIdx:
+			if len(currLine) == 0 {
+				break
+			}
+
+			paused, err := strconv.ParseBool(currLine)
+			if err != nil {
+				return SchedulingState{}, fmt.Errorf("invalid paused '%s': %w", currLine, err)
+			}
+
+			newState.Paused = paused
+
+		case fieldRolesIdx:
+			roles, err := ParseRoles(currLine)
+			if err != nil {
+				return SchedulingState{}, fmt.Errorf("invalid roles '%s': %w", currLine, err)
+			}
+
+			newState.Roles = roles
+
+		case fieldRegionIdx:
+			newState.Region = currLine
+
+		case fieldRegionsIdx:
+			positions, err := ParsePositions(currLine)
+			if err != nil {
+				return SchedulingState{}, fmt.Errorf("invalid regions '%s': %w", currLine, err)
+			}
+
+			newState.RegionPositions = positions
+
+		case fieldTotalsIdx:
+			totals, err := ParseTotals(currLine)
+			if err != nil {
+				return SchedulingState{}, fmt.Errorf("invalid totals '%s': %w", currLine, err)
+			}
+
+			newState.Totals = totals
+
+		case fieldCountedTillIdx:
+			if len(currLine) == 0 {
+				break
+			}
+
+			ts, err := strconv.Atoi(currLine)
+			if err != nil {
+				return SchedulingState{}, fmt.Errorf("invalid counted till '%s': %w", currLine, err)
+			}
+
+			newState.CountedTill = time.Unix(int64(ts), 0)
+
+		case fieldLastDutyIdx:
+			lastDuty, err := ParseTimes(currLine)
+			if err != nil {
+				return SchedulingState{}, fmt.Errorf("invalid last duty '%s': %w", currLine, err)
+			}
+
+			newState.LastDuty = lastDuty
+
+		case fieldStreakIdx:
+			if len(currLine) == 0 {
+				break
+			}
+
+			streak, err := strconv.Atoi(currLine)
+			if err != nil {
+				return SchedulingState{}, fmt.Errorf("invalid streak '%s': %w", currLine, err)
+			}
+
+			newState.Streak = streak
+
+		case fieldCurrentNameIdx:
+			newState.CurrentName = currLine
+
+		case fieldRotationIdx:
+			if len(currLine) != 0 {
+				newState.Rotation = strings.Split(currLine, swapsSeparator)
+			}
+
+		case fieldRegionRotationIdx:
+			rotations, err := ParseRotations(currLine)
+			if err != nil {
+				return SchedulingState{}, fmt.Errorf(
+					"invalid region rotations '%s': %w", currLine, err,
+				)
+			}
+
+			newState.RegionRotations = rotations
 		}
 
 		linesParsed++
@@ -80,6 +367,209 @@ func NewSchedulingState(r io.Reader) (SchedulingState, error) {
 	return newState, nil
 }
 
+// FormatSwaps serialises swaps into a single line in a stable order.
+func FormatSwaps(swaps map[string]string) string {
+	return formatPairs(swaps)
+}
+
+// FormatRoles serialises holders of roles into a single line in a stable order.
+func FormatRoles(roles map[string]string) string {
+	return formatPairs(roles)
+}
+
+// FormatPositions serialises positions of rotations into a single line in a stable order.
+func FormatPositions(positions map[string]uint64) string {
+	pairs := make(map[string]string, len(positions))
+
+	for key, position := range positions {
+		pairs[key] = strconv.FormatUint(position, 10)
+	}
+
+	return formatPairs(pairs)
+}
+
+// FormatTotals serialises time on duty of applicants into a single line in a stable
+// order, durations are given in seconds.
+func FormatTotals(totals map[string]DutyTotals) string {
+	pairs := make(map[string]string, len(totals))
+
+	for key, total := range totals {
+		pairs[key] = strconv.FormatInt(int64(total.Duty.Seconds()), 10) + totalsSeparator +
+			strconv.FormatInt(int64(total.DayOffs.Seconds()), 10)
+	}
+
+	return formatPairs(pairs)
+}
+
+// FormatTimes serialises times of applicants into a single line in a stable order,
+// times are given as unix timestamps.
+func FormatTimes(times map[string]time.Time) string {
+	pairs := make(map[string]string, len(times))
+
+	for key, t := range times {
+		pairs[key] = strconv.FormatInt(t.Unix(), 10)
+	}
+
+	return formatPairs(pairs)
+}
+
+// FormatRotation serialises the order of a rotation into a single line.
+func FormatRotation(rotation []string) string {
+	return strings.Join(rotation, swapsSeparator)
+}
+
+// FormatRotations serialises orders of rotations into a single line in a stable order.
+func FormatRotations(rotations map[string][]string) string {
+	pairs := make(map[string]string, len(rotations))
+
+	for key, rotation := range rotations {
+		pairs[key] = strings.Join(rotation, rotationSeparator)
+	}
+
+	return formatPairs(pairs)
+}
+
+func formatPairs(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+
+	for key, value := range m {
+		pairs = append(pairs, key+swapPairSeparator+value)
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, swapsSeparator)
+}
+
+// FormatSkips serialises skips into a single line in a stable order.
+func FormatSkips(skips []string) string {
+	sorted := append([]string(nil), skips...)
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, swapsSeparator)
+}
+
+// ParseSwaps parses swaps serialised with FormatSwaps.
+func ParseSwaps(s string) (map[string]string, error) {
+	return parsePairs(s, ErrInvalidSwaps)
+}
+
+// ParseRoles parses holders of roles serialised with FormatRoles.
+func ParseRoles(s string) (map[string]string, error) {
+	return parsePairs(s, ErrInvalidRoles)
+}
+
+// ParsePositions parses positions of rotations serialised with FormatPositions.
+func ParsePositions(s string) (map[string]uint64, error) {
+	pairs, err := parsePairs(s, ErrInvalidPositions)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]uint64, len(pairs))
+
+	for key, value := range pairs {
+		position, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("position '%s': %w", value, ErrInvalidPositions)
+		}
+
+		positions[key] = position
+	}
+
+	return positions, nil
+}
+
+// ParseTotals parses time on duty of applicants serialised with FormatTotals.
+func ParseTotals(s string) (map[string]DutyTotals, error) {
+	pairs, err := parsePairs(s, ErrInvalidTotals)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]DutyTotals, len(pairs))
+
+	for key, value := range pairs {
+		durations := strings.Split(value, totalsSeparator)
+		if len(durations) != 2 { // nolint: gomnd
+			return nil, fmt.Errorf("totals '%s': %w", value, ErrInvalidTotals)
+		}
+
+		duty, err := strconv.ParseInt(durations[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("duty '%s': %w", durations[0], ErrInvalidTotals)
+		}
+
+		dayOffs, err := strconv.ParseInt(durations[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("day offs '%s': %w", durations[1], ErrInvalidTotals)
+		}
+
+		totals[key] = DutyTotals{
+			Duty:    time.Duration(duty) * time.Second,
+			DayOffs: time.Duration(dayOffs) * time.Second,
+		}
+	}
+
+	return totals, nil
+}
+
+// ParseTimes parses times of applicants serialised with FormatTimes.
+func ParseTimes(s string) (map[string]time.Time, error) {
+	pairs, err := parsePairs(s, ErrInvalidTimes)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[string]time.Time, len(pairs))
+
+	for key, value := range pairs {
+		ts, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("time '%s': %w", value, ErrInvalidTimes)
+		}
+
+		times[key] = time.Unix(ts, 0)
+	}
+
+	return times, nil
+}
+
+// ParseRotations parses orders of rotations serialised with FormatRotations.
+func ParseRotations(s string) (map[string][]string, error) {
+	pairs, err := parsePairs(s, ErrInvalidRotations)
+	if err != nil {
+		return nil, err
+	}
+
+	rotations := make(map[string][]string, len(pairs))
+
+	for key, value := range pairs {
+		rotations[key] = strings.Split(value, rotationSeparator)
+	}
+
+	return rotations, nil
+}
+
+func parsePairs(s string, errInvalid error) (map[string]string, error) {
+	m := make(map[string]string)
+
+	if len(s) == 0 {
+		return m, nil
+	}
+
+	for _, pair := range strings.Split(s, swapsSeparator) {
+		kv := strings.Split(pair, swapPairSeparator)
+		if len(kv) != 2 || len(kv[0]) == 0 || len(kv[1]) == 0 { // nolint: gomnd
+			return nil, fmt.Errorf("pair '%s': %w", pair, errInvalid)
+		}
+
+		m[kv[0]] = kv[1]
+	}
+
+	return m, nil
+}
+
 func IsStateFile(s string) bool {
 	return strings.HasSuffix(s, diskSuffix)
 }
@@ -1,6 +1,7 @@
 package statedumper
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -15,7 +16,71 @@ func TestNewSchedulingState(t *testing.T) {
 	testcases := []schedulingStateTestcase{
 		{
 			"mailx\n0\n1609074301",
-			SchedulingState{"mailx", 0, time.Unix(1609074301, 0)},
+			SchedulingState{
+				Name: "mailx", CurrentPerson: 0, TimeOfLastChange: time.Unix(1609074301, 0),
+			},
+		},
+		{
+			"mailx\n1\n1609074301\ntest2\ntest1=test3,test3=test1",
+			SchedulingState{
+				Name: "mailx", CurrentPerson: 1, TimeOfLastChange: time.Unix(1609074301, 0),
+				Substitute: "test2",
+				Swaps:      map[string]string{"test1": "test3", "test3": "test1"},
+			},
+		},
+		{
+			"mailx\n1\n1609074301\n\n\ntest2,test3\ntrue",
+			SchedulingState{
+				Name: "mailx", CurrentPerson: 1, TimeOfLastChange: time.Unix(1609074301, 0),
+				Skips:  []string{"test2", "test3"},
+				Paused: true,
+			},
+		},
+		{
+			"mailx\n1\n1609074301\n\n\n\nfalse\nbackup=test3",
+			SchedulingState{
+				Name: "mailx", CurrentPerson: 1, TimeOfLastChange: time.Unix(1609074301, 0),
+				Roles: map[string]string{"backup": "test3"},
+			},
+		},
+		{
+			"mailx\n1\n1609074301\n\n\n\nfalse\n\namer\namer=3,emea=18446744073709551615",
+			SchedulingState{
+				Name: "mailx", CurrentPerson: 1, TimeOfLastChange: time.Unix(1609074301, 0),
+				Region:          "amer",
+				RegionPositions: map[string]uint64{"amer": 3, "emea": 18446744073709551615},
+			},
+		},
+		{
+			"mailx\n1\n1609074301\n\n\n\nfalse\n\n\n\ntest1=129600:43200,test2=86400:0\n1609074401",
+			SchedulingState{
+				Name: "mailx", CurrentPerson: 1, TimeOfLastChange: time.Unix(1609074301, 0),
+				Totals: map[string]DutyTotals{
+					"test1": {Duty: 36 * time.Hour, DayOffs: 12 * time.Hour},
+					"test2": {Duty: 24 * time.Hour},
+				},
+				CountedTill: time.Unix(1609074401, 0),
+			},
+		},
+		{
+			"mailx\n1\n1609074301\n\n\n\nfalse\n\n\n\n\n\ntest1=1609074301,test2=1608987901\n2",
+			SchedulingState{
+				Name: "mailx", CurrentPerson: 1, TimeOfLastChange: time.Unix(1609074301, 0),
+				LastDuty: map[string]time.Time{
+					"test1": time.Unix(1609074301, 0),
+					"test2": time.Unix(1608987901, 0),
+				},
+				Streak: 2,
+			},
+		},
+		{
+			"mailx\n1\n1609074301\n\n\n\nfalse\n\n\n\n\n\n\n\ntest2\ntest1,test2\namer=amer1|amer2",
+			SchedulingState{
+				Name: "mailx", CurrentPerson: 1, TimeOfLastChange: time.Unix(1609074301, 0),
+				CurrentName:     "test2",
+				Rotation:        []string{"test1", "test2"},
+				RegionRotations: map[string][]string{"amer": {"amer1", "amer2"}},
+			},
 		},
 	}
 
@@ -44,16 +109,109 @@ func TestNewSchedulingState(t *testing.T) {
 			)
 			continue
 		}
+		if state.Substitute != testcase.output.Substitute {
+			t.Errorf("expected '%s', got '%s'",
+				testcase.output.Substitute, state.Substitute,
+			)
+			continue
+		}
+		if FormatSwaps(state.Swaps) != FormatSwaps(testcase.output.Swaps) {
+			t.Errorf("expected '%v', got '%v'",
+				testcase.output.Swaps, state.Swaps,
+			)
+			continue
+		}
+		if FormatSkips(state.Skips) != FormatSkips(testcase.output.Skips) {
+			t.Errorf("expected '%v', got '%v'",
+				testcase.output.Skips, state.Skips,
+			)
+			continue
+		}
+		if state.Paused != testcase.output.Paused {
+			t.Errorf("expected '%t', got '%t'",
+				testcase.output.Paused, state.Paused,
+			)
+			continue
+		}
+		if FormatRoles(state.Roles) != FormatRoles(testcase.output.Roles) {
+			t.Errorf("expected '%v', got '%v'",
+				testcase.output.Roles, state.Roles,
+			)
+			continue
+		}
+		if state.Region != testcase.output.Region {
+			t.Errorf("expected '%s', got '%s'",
+				testcase.output.Region, state.Region,
+			)
+			continue
+		}
+		if FormatPositions(state.RegionPositions) != FormatPositions(testcase.output.RegionPositions) {
+			t.Errorf("expected '%v', got '%v'",
+				testcase.output.RegionPositions, state.RegionPositions,
+			)
+			continue
+		}
+		if FormatTotals(state.Totals) != FormatTotals(testcase.output.Totals) {
+			t.Errorf("expected '%v', got '%v'",
+				testcase.output.Totals, state.Totals,
+			)
+			continue
+		}
+		if !state.CountedTill.Equal(testcase.output.CountedTill) {
+			t.Errorf("expected '%v', got '%v'",
+				testcase.output.CountedTill, state.CountedTill,
+			)
+			continue
+		}
+		if FormatTimes(state.LastDuty) != FormatTimes(testcase.output.LastDuty) {
+			t.Errorf("expected '%v', got '%v'",
+				testcase.output.LastDuty, state.LastDuty,
+			)
+			continue
+		}
+		if state.Streak != testcase.output.Streak {
+			t.Errorf("expected '%d', got '%d'",
+				testcase.output.Streak, state.Streak,
+			)
+			continue
+		}
+		if state.CurrentName != testcase.output.CurrentName {
+			t.Errorf("expected '%s', got '%s'",
+				testcase.output.CurrentName, state.CurrentName,
+			)
+			continue
+		}
+		if FormatRotation(state.Rotation) != FormatRotation(testcase.output.Rotation) {
+			t.Errorf("expected '%v', got '%v'",
+				testcase.output.Rotation, state.Rotation,
+			)
+			continue
+		}
+		if FormatRotations(state.RegionRotations) != FormatRotations(testcase.output.RegionRotations) {
+			t.Errorf("expected '%v', got '%v'",
+				testcase.output.RegionRotations, state.RegionRotations,
+			)
+			continue
+		}
 	}
 }
 
 func TestNewSchedulingStatFails(t *testing.T) {
 	testcases := []schedulingStateTestcase{
-		{input: ""},              // empty
-		{input: "mailx"},         // missing two fields
-		{input: "mailx\n-1"},     // invalid current person
-		{input: "mailx\n1"},      // invalid one field
-		{input: "mailx\n1\nasd"}, // invalid ts of last change
+		{input: ""},                                                         // empty
+		{input: "mailx"},                                                    // missing two fields
+		{input: "mailx\n-1"},                                                // invalid current person
+		{input: "mailx\n1"},                                                 // invalid one field
+		{input: "mailx\n1\nasd"},                                            // invalid ts of last change
+		{input: "mailx\n1\n1609074301\n\ntest1"},                            // invalid swaps
+		{input: "mailx\n1\n1609074301\n\n\n\nasd"},                          // invalid paused
+		{input: "mailx\n1\n1609074301\n\n\n\n\nbackup"},                     // invalid roles
+		{input: "mailx\n1\n1609074301\n\n\n\n\n\n\nemea=x"},                 // invalid region positions
+		{input: "mailx\n1\n1609074301\n\n\n\n\n\n\n\ntest1=1"},              // invalid totals
+		{input: "mailx\n1\n1609074301\n\n\n\n\n\n\n\n\nasd"},                // invalid counted till
+		{input: "mailx\n1\n1609074301\n\n\n\n\n\n\n\n\n\ntest1=x"},          // invalid last duty
+		{input: "mailx\n1\n1609074301\n\n\n\n\n\n\n\n\n\n\nasd"},            // invalid streak
+		{input: "mailx\n1\n1609074301" + strings.Repeat("\n", 14) + "amer"}, // invalid region rotations
 	}
 
 	for _, testcase := range testcases {
@@ -64,3 +222,79 @@ func TestNewSchedulingStatFails(t *testing.T) {
 		}
 	}
 }
+
+func TestSchedulingStateJSON(t *testing.T) {
+	state := SchedulingState{
+		Name: "mailx", CurrentPerson: 18446744073709551615,
+		TimeOfLastChange: time.Unix(1609074301, 5),
+		Swaps:            map[string]string{"test1": "test3", "test3": "test1"},
+		Skips:            []string{"test2"},
+		Totals:           map[string]DutyTotals{"test1": {Duty: 36 * time.Hour, DayOffs: 12 * time.Hour}},
+		LastDuty:         map[string]time.Time{"test1": time.Unix(1609074301, 0)},
+		Rotation:         []string{"test1", "test2", "test3"},
+		RegionRotations:  map[string][]string{"amer": {"amer1", "amer2"}},
+	}
+
+	s, err := FormatState(state)
+	if err != nil {
+		t.Fatalf("could not format state: %v", err)
+	}
+
+	restored, err := NewSchedulingState(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("could not parse state '%s': %v", s, err)
+	}
+
+	if redumped, _ := FormatState(restored); redumped != s {
+		t.Errorf("expected '%s', got '%s'", s, redumped)
+	}
+	if !restored.TimeOfLastChange.Equal(state.TimeOfLastChange) {
+		t.Errorf("expected '%v', got '%v'", state.TimeOfLastChange, restored.TimeOfLastChange)
+	}
+	if restored.CurrentPerson != state.CurrentPerson {
+		t.Errorf("expected '%d', got '%d'", state.CurrentPerson, restored.CurrentPerson)
+	}
+	if FormatTotals(restored.Totals) != FormatTotals(state.Totals) {
+		t.Errorf("expected '%v', got '%v'", state.Totals, restored.Totals)
+	}
+	if restored.Version != SchemaVersion {
+		t.Errorf("expected '%d', got '%d'", SchemaVersion, restored.Version)
+	}
+
+	// legacy states are migrated to the current version
+	legacy, err := NewSchedulingState(strings.NewReader("mailx\n1\n1609074301"))
+	if err != nil {
+		t.Fatalf("could not parse legacy state: %v", err)
+	}
+
+	if legacy.Version != SchemaVersion {
+		t.Errorf("expected '%d', got '%d'", SchemaVersion, legacy.Version)
+	}
+}
+
+func TestSchedulingStateJSONFails(t *testing.T) {
+	testcases := []struct {
+		input string
+		err   error
+	}{
+		{`{"version": 1, "name": "mailx"`, nil},                                 // truncated
+		{`{"version": 2, "name": "mailx"}`, ErrUnsupportedVersion},              // newer version
+		{`{"name": "mailx"}`, ErrUnsupportedVersion},                            // no version
+		{`{"version": 1}`, ErrInsufficientStateFile},                            // no name
+		{`{"version": 1, "name": "mailx", "totals": {"test1": 1}}`, nil},        // invalid totals
+		{`{"version": 1, "name": "mailx", "current_person": -1}`, nil},          // invalid current person
+		{"  \n{\"version\": 1, \"name\": \"mailx\", \"paused\": \"yes\"}", nil}, // invalid paused
+	}
+
+	for _, testcase := range testcases {
+		_, err := NewSchedulingState(strings.NewReader(testcase.input))
+		if err == nil {
+			t.Errorf("testcase '%s': must have failed", testcase.input)
+			continue
+		}
+
+		if testcase.err != nil && !errors.Is(err, testcase.err) {
+			t.Errorf("testcase '%s': expected '%v', got '%v'", testcase.input, testcase.err, err)
+		}
+	}
+}
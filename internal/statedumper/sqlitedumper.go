@@ -0,0 +1,326 @@
+package statedumper
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// registers the pure Go SQLite driver
+	_ "modernc.org/sqlite"
+
+	"github.com/gibsn/duty_bot/internal/outbox"
+)
+
+const (
+	// SQLiteFileName is the name of the database in the state directory
+	SQLiteFileName = "duty_bot.db"
+
+	sqliteDriverName  = "sqlite"
+	sqliteBusyTimeout = 5 * time.Second
+)
+
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS states (
+		name       TEXT PRIMARY KEY,
+		state      TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS outbox (
+		project TEXT NOT NULL,
+		id      INTEGER NOT NULL,
+		entry   TEXT NOT NULL,
+		PRIMARY KEY (project, id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS history (
+		project TEXT NOT NULL,
+		time    INTEGER NOT NULL,
+		person  TEXT NOT NULL,
+		reason  TEXT NOT NULL,
+		region  TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS history_project_time ON history (project, time)`,
+}
+
+// SQLiteDumper is an implementation of a StateDumper that keeps states of the
+// projects along with their outboxes and duty history in a single SQLite database.
+// Every change is saved in a transaction.
+type SQLiteDumper struct {
+	db *sql.DB
+}
+
+// NewSQLiteDumper opens the database at the given path, creating it if needed.
+func NewSQLiteDumper(path string) (*SQLiteDumper, error) {
+	if err := os.MkdirAll(filepath.Dir(path), stateDirPerm); err != nil {
+		return nil, fmt.Errorf("could not create dir: %w", err)
+	}
+
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeout.Milliseconds()))
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "synchronous(FULL)")
+
+	db, err := sql.Open(sqliteDriverName, "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("could not open database '%s': %w", path, err)
+	}
+
+	// writes are serialised by SQLite anyway
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range sqliteSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("could not init database '%s': %w", path, err)
+		}
+	}
+
+	return &SQLiteDumper{db: db}, nil
+}
+
+// Dump saves the given project state synchronously.
+func (sd *SQLiteDumper) Dump(state Dumpable) error {
+	buf := &strings.Builder{}
+
+	if err := state.DumpState(buf); err != nil {
+		return err
+	}
+
+	return sd.saveState(state.Name(), buf.String())
+}
+
+// SaveState saves the given state as is.
+func (sd *SQLiteDumper) SaveState(state SchedulingState) error {
+	s, err := FormatState(state)
+	if err != nil {
+		return err
+	}
+
+	return sd.saveState(state.Name, s)
+}
+
+func (sd *SQLiteDumper) saveState(name, s string) error {
+	_, err := sd.db.Exec(
+		`INSERT INTO states (name, state, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET state = excluded.state, updated_at = excluded.updated_at`,
+		name, s, time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("could not save state of '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+// GetState attempts to find a SchedulingState for the provided name. It returns
+// ErrNotFound in case state is not present.
+func (sd *SQLiteDumper) GetState(name string) (SchedulingState, error) {
+	var s string
+
+	err := sd.db.QueryRow(`SELECT state FROM states WHERE name = ?`, name).Scan(&s)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SchedulingState{}, ErrNotFound
+	}
+	if err != nil {
+		return SchedulingState{}, fmt.Errorf("could not read state of '%s': %w", name, err)
+	}
+
+	return NewSchedulingState(strings.NewReader(s))
+}
+
+// AppendHistory appends the given records to the history of the project in a transaction.
+func (sd *SQLiteDumper) AppendHistory(project string, records ...HistoryRecord) (err error) {
+	if len(records) == 0 {
+		return nil
+	}
+
+	tx, err := sd.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback() //nolint: errcheck
+		}
+	}()
+
+	for _, r := range records {
+		_, err = tx.Exec(
+			`INSERT INTO history (project, time, person, reason, region) VALUES (?, ?, ?, ?, ?)`,
+			project, r.Time.UnixNano(), r.Person, string(r.Reason), r.Region,
+		)
+		if err != nil {
+			return fmt.Errorf("could not save history of '%s': %w", project, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit history of '%s': %w", project, err)
+	}
+
+	return nil
+}
+
+// History returns the records of the history of the project made in [from, till)
+// in chronological order, zero bounds are not checked.
+func (sd *SQLiteDumper) History(project string, from, till time.Time) ([]HistoryRecord, error) {
+	query := `SELECT time, person, reason, region FROM history WHERE project = ?`
+	args := []interface{}{project}
+
+	if !from.IsZero() {
+		query += ` AND time >= ?`
+		args = append(args, from.UnixNano())
+	}
+
+	if !till.IsZero() {
+		query += ` AND time < ?`
+		args = append(args, till.UnixNano())
+	}
+
+	rows, err := sd.db.Query(query+` ORDER BY time, rowid`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not read history of '%s': %w", project, err)
+	}
+
+	defer rows.Close()
+
+	var records []HistoryRecord
+
+	for rows.Next() {
+		var (
+			r      HistoryRecord
+			nanos  int64
+			reason string
+		)
+
+		if err := rows.Scan(&nanos, &r.Person, &reason, &r.Region); err != nil {
+			return nil, fmt.Errorf("could not read history of '%s': %w", project, err)
+		}
+
+		r.Time = time.Unix(0, nanos)
+		r.Reason = HistoryReason(reason)
+
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read history of '%s': %w", project, err)
+	}
+
+	return records, nil
+}
+
+// HasHistory reports whether there are any history records of the project.
+func (sd *SQLiteDumper) HasHistory(project string) (bool, error) {
+	var n int
+
+	err := sd.db.QueryRow(`SELECT COUNT(*) FROM history WHERE project = ?`, project).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("could not read history of '%s': %w", project, err)
+	}
+
+	return n != 0, nil
+}
+
+// OutboxStore returns the store of the outbox of the given project.
+func (sd *SQLiteDumper) OutboxStore(project string) outbox.Store {
+	return sqliteOutboxStore{db: sd.db, project: project}
+}
+
+// Shutdown closes the database. Calling Dump after Shutdown results in an error.
+func (sd *SQLiteDumper) Shutdown() {
+	log.Print("info: sqlitedumper: shutting down")
+
+	if err := sd.db.Close(); err != nil {
+		log.Printf("error: sqlitedumper: could not close database: %v", err)
+	}
+
+	log.Print("info: sqlitedumper: shutdown finished")
+}
+
+// sqliteOutboxStore keeps the entries of an outbox as rows of outbox table
+type sqliteOutboxStore struct {
+	db      *sql.DB
+	project string
+}
+
+func (s sqliteOutboxStore) Load() ([]outbox.Entry, error) {
+	rows, err := s.db.Query(
+		`SELECT entry FROM outbox WHERE project = ? ORDER BY id`, s.project,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not read outbox: %w", err)
+	}
+
+	defer rows.Close()
+
+	var entries []outbox.Entry
+
+	for rows.Next() {
+		var data string
+
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("could not read outbox: %w", err)
+		}
+
+		var e outbox.Entry
+
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return nil, fmt.Errorf("could not parse outbox entry: %w", err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read outbox: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Save replaces the entries of the outbox in a transaction
+func (s sqliteOutboxStore) Save(entries []outbox.Entry) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback() //nolint: errcheck
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM outbox WHERE project = ?`, s.project); err != nil {
+		return fmt.Errorf("could not clear outbox: %w", err)
+	}
+
+	for _, e := range entries {
+		var data []byte
+
+		if data, err = json.Marshal(e); err != nil {
+			return fmt.Errorf("could not marshal outbox entry: %w", err)
+		}
+
+		_, err = tx.Exec(
+			`INSERT INTO outbox (project, id, entry) VALUES (?, ?, ?)`, s.project, e.ID, string(data),
+		)
+		if err != nil {
+			return fmt.Errorf("could not save outbox entry: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit outbox: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package statedumper
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gibsn/duty_bot/internal/notifychannel"
+	"github.com/gibsn/duty_bot/internal/outbox"
+)
+
+func TestSQLiteDumper(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "states", SQLiteFileName)
+
+	sd, err := NewSQLiteDumper(path)
+	if err != nil {
+		t.Fatalf("could not create sqlite dumper: %v", err)
+	}
+
+	_, err = sd.GetState("test1")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, sd.Dump(dumpableMock{"test1", "test1\n0\n1609074301\n"}))
+	assert.NoError(t, sd.Dump(dumpableMock{"test1", "test1\n1\n1609074302\n"}))
+	assert.NoError(t, sd.SaveState(SchedulingState{Name: "test2", Rotation: []string{"test3"}}))
+
+	o, err := outbox.NewWithStore(sd.OutboxStore("test1"))
+	assert.NoError(t, err)
+	assert.NoError(t, o.Push("stdout[0]", notifychannel.Notification{Text: "test1 is on duty"}))
+	assert.NoError(t, o.Push("stdout[0]", notifychannel.Notification{Text: "test2 is on duty"}))
+	assert.NoError(t, o.Remove(1))
+
+	sd.Shutdown()
+
+	// everything survives restarts
+	sd, err = NewSQLiteDumper(path)
+	if err != nil {
+		t.Fatalf("could not reopen sqlite dumper: %v", err)
+	}
+
+	defer sd.Shutdown()
+
+	state, err := sd.GetState("test1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), state.CurrentPerson)
+	assert.Equal(t, SchemaVersion, state.Version)
+
+	state, err = sd.GetState("test2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test3"}, state.Rotation)
+
+	o, err = outbox.NewWithStore(sd.OutboxStore("test1"))
+	assert.NoError(t, err)
+
+	if entries := o.Entries(); assert.Len(t, entries, 1) {
+		assert.Equal(t, uint64(2), entries[0].ID)
+		assert.Equal(t, "test2 is on duty", entries[0].Notification.Text)
+	}
+
+	o, err = outbox.NewWithStore(sd.OutboxStore("test2"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, o.Len())
+}
+
+func TestSQLiteDumperHistory(t *testing.T) {
+	sd, err := NewSQLiteDumper(filepath.Join(t.TempDir(), SQLiteFileName))
+	if err != nil {
+		t.Fatalf("could not create sqlite dumper: %v", err)
+	}
+
+	defer sd.Shutdown()
+
+	ok, err := sd.HasHistory("test_project")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	testHistory(t, sd)
+
+	ok, err = sd.HasHistory("test_project")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
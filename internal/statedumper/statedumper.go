@@ -3,8 +3,19 @@ package statedumper
 import (
 	"errors"
 	"io"
+	"time"
 )
 
+// StateDumper saves states of the projects and restores them after restarts.
+// It also keeps the append-only duty history of the projects.
+type StateDumper interface {
+	Dump(state Dumpable) error
+	GetState(name string) (SchedulingState, error)
+	AppendHistory(project string, records ...HistoryRecord) error
+	History(project string, from, till time.Time) ([]HistoryRecord, error)
+	Shutdown()
+}
+
 type Dumpable interface {
 	DumpState(dst io.StringWriter) error
 	Name() string